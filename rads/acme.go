@@ -0,0 +1,481 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	mathrand "math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"golang.org/x/crypto/acme"
+	"gorm.io/gorm"
+)
+
+// defaultACMERenewBeforeDays / defaultACMERenewJitterMinutes are the ACMEConfig fallbacks used
+// when the operator leaves RenewBeforeDays / RenewJitterMinutes unset.
+const (
+	defaultACMERenewBeforeDays     = 30
+	defaultACMERenewJitterMinutes  = 30
+	defaultACMEChallengeListenAddr = "127.0.0.1:9080"
+)
+
+// DNSProvider is implemented per-DNS-host so DNS-01 challenges can be satisfied without the
+// renewer knowing anything about the specific API it's talking to.
+type DNSProvider interface {
+	// Present creates the _acme-challenge TXT record for domain with the given value.
+	Present(ctx context.Context, domain string, value string) error
+	// CleanUp removes the TXT record created by Present.
+	CleanUp(ctx context.Context, domain string, value string) error
+}
+
+// dnsProviderFor resolves the DNSProvider implementation named on an ACMEChallenge row.
+// Only the name is wired here; credential parsing lives with each provider.
+func dnsProviderFor(name string, config string) (DNSProvider, error) {
+	switch name {
+	case "cloudflare":
+		return newCloudflareDNSProvider(config)
+	case "route53":
+		return newRoute53DNSProvider(config)
+	case "rfc2136":
+		return newRFC2136DNSProvider(config)
+	default:
+		return nil, fmt.Errorf("unknown dns-01 provider: %s", name)
+	}
+}
+
+// httpChallengeStore holds in-flight HTTP-01 key authorizations keyed by token, served by
+// acmeHTTP01Server. It's deliberately process-local: a token only needs to survive the few
+// seconds between order creation and the CA's validation request.
+type httpChallengeStore struct {
+	mu     chan struct{} // 1-buffered channel used as a cheap mutex
+	tokens map[string]string
+}
+
+func newHTTPChallengeStore() *httpChallengeStore {
+	s := &httpChallengeStore{mu: make(chan struct{}, 1), tokens: map[string]string{}}
+	s.mu <- struct{}{}
+	return s
+}
+
+func (s *httpChallengeStore) put(token, keyAuth string) {
+	<-s.mu
+	s.tokens[token] = keyAuth
+	s.mu <- struct{}{}
+}
+
+func (s *httpChallengeStore) delete(token string) {
+	<-s.mu
+	delete(s.tokens, token)
+	s.mu <- struct{}{}
+}
+
+func (s *httpChallengeStore) get(token string) (string, bool) {
+	<-s.mu
+	keyAuth, ok := s.tokens[token]
+	s.mu <- struct{}{}
+	return keyAuth, ok
+}
+
+// acmeHTTP01Server serves ACME HTTP-01 challenge responses on the agent's local
+// ChallengeListenAddr. Port 80 itself is fronted by a regular Listener/IngressRule pair (see
+// registerHTTP01IngressRule) so the CA's validation request travels through the same proxy path
+// as any other ingress traffic, and only the final hop to this process is agent-local.
+func (m *Manager) acmeHTTP01Server(store *httpChallengeStore) *http.Server {
+	addr := m.Config.ACME.ChallengeListenAddr
+	if addr == "" {
+		addr = defaultACMEChallengeListenAddr
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/acme-challenge/", func(w http.ResponseWriter, r *http.Request) {
+		m.Metrics.incListenerConnections(addr)
+		defer m.Metrics.decListenerConnections(addr)
+
+		token := strings.TrimPrefix(r.URL.Path, "/.well-known/acme-challenge/")
+		keyAuth, ok := store.get(token)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(keyAuth))
+	})
+	return &http.Server{Addr: addr, Handler: mux}
+}
+
+// http01IngressRuleID returns the deterministic ID of the IngressRule registerHTTP01IngressRule
+// creates below, so applySnapshotRows (snapshot.go) can keep it alive across a config snapshot
+// even though no submitted IngressRuleUpsertV1 names it.
+func http01IngressRuleID() string {
+	return getIngressRuleID(HTTP, getListenerID("0.0.0.0", 80), "*", "/.well-known/acme-challenge/")
+}
+
+// registerHTTP01IngressRule makes sure a Listener/Backend/IngressRule triple exists routing
+// "*" + "/.well-known/acme-challenge/" on port 80 to acmeHTTP01Server, the same way any other
+// ingress rule is created -- so HTTP-01 validation traffic is served by the normal proxy fleet
+// rather than requiring the agent itself to bind :80.
+func (m *Manager) registerHTTP01IngressRule() error {
+	addr := m.Config.ACME.ChallengeListenAddr
+	if addr == "" {
+		addr = defaultACMEChallengeListenAddr
+	}
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid challenge_listen_addr %q: %w", addr, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return fmt.Errorf("invalid challenge_listen_addr %q: %w", addr, err)
+	}
+
+	listener, err := upsertListener(m.ReadWriteDB, "0.0.0.0", 80, HTTP, false)
+	if err != nil {
+		return fmt.Errorf("failed to upsert HTTP-01 listener: %w", err)
+	}
+
+	backend, err := upsertBackend(m.ReadWriteDB, STATIC_RESOLVER, "", []string{host}, port, false, "", "", "", 0, 0, 0, 0, true, "", nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to upsert HTTP-01 backend: %w", err)
+	}
+
+	ingressRule, err := upsertIngressRule(m.ReadWriteDB, HTTP, listener.ID, "*", "/.well-known/acme-challenge/", nil, nil, 0)
+	if err != nil {
+		return fmt.Errorf("failed to upsert HTTP-01 ingress rule: %w", err)
+	}
+
+	plans := []ingressRuleBackendPlan{{BackendID: backend.ID, Weight: 1}}
+	if err := setIngressRuleBackends(m.ReadWriteDB, ingressRule.ID, plans); err != nil {
+		return fmt.Errorf("failed to attach HTTP-01 backend: %w", err)
+	}
+
+	m.BroadcastChangesToProxies(TypeURLIngressRules, TypeURLListeners, TypeURLBackends)
+	return nil
+}
+
+// newACMEAccountKey generates the ECDSA key pair an ACME account is registered with.
+func newACMEAccountKey() (*ecdsa.PrivateKey, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, "", err
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	return key, string(pemBytes), nil
+}
+
+func parseACMEAccountKey(keyPEM string) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("invalid ACME account key PEM")
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
+
+// acmeClientFor loads (or registers, if absent) the ACME account for email and returns a ready
+// to use client bound to directoryURL.
+func acmeClientFor(db *gorm.DB, email string, directoryURL string) (*acme.Client, error) {
+	account, err := getACMEAccountByEmail(db, email)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		key, keyPEM, err := newACMEAccountKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ACME account key: %w", err)
+		}
+
+		client := &acme.Client{Key: key, DirectoryURL: directoryURL}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if _, err := client.Register(ctx, &acme.Account{Contact: []string{"mailto:" + email}}, acme.AcceptTOS); err != nil {
+			return nil, fmt.Errorf("failed to register ACME account: %w", err)
+		}
+
+		if _, err := upsertACMEAccount(db, email, directoryURL, keyPEM, "", ""); err != nil {
+			return nil, fmt.Errorf("failed to persist ACME account: %w", err)
+		}
+		return client, nil
+	}
+
+	key, err := parseACMEAccountKey(account.KeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ACME account key: %w", err)
+	}
+	return &acme.Client{Key: key, DirectoryURL: account.DirectoryURL}, nil
+}
+
+// issueCertificate runs a full ACME order for domain (HTTP-01 or DNS-01, chosen via the
+// domain's ACMEChallenge row, defaulting to HTTP-01) and returns the PEM-encoded leaf cert
+// (with chain) and key.
+func (m *Manager) issueCertificate(ctx context.Context, client *acme.Client, store *httpChallengeStore, domain string) (certPEM string, keyPEM string, err error) {
+	challengeCfg, err := getACMEChallengeForDomain(m.ReadOnlyDB, domain)
+	if err != nil {
+		return "", "", err
+	}
+	challengeType := "http-01"
+	if challengeCfg != nil && challengeCfg.ChallengeType != "" {
+		challengeType = challengeCfg.ChallengeType
+	}
+
+	order, err := client.AuthorizeOrder(ctx, []acme.AuthzID{{Type: "dns", Value: domain}})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create ACME order for %s: %w", domain, err)
+	}
+
+	for _, authzURL := range order.AuthzURLs {
+		authz, err := client.GetAuthorization(ctx, authzURL)
+		if err != nil {
+			return "", "", err
+		}
+
+		var chal *acme.Challenge
+		for _, c := range authz.Challenges {
+			if c.Type == challengeType {
+				chal = c
+				break
+			}
+		}
+		if chal == nil {
+			return "", "", fmt.Errorf("no %s challenge offered for %s", challengeType, domain)
+		}
+
+		switch challengeType {
+		case "http-01":
+			keyAuth, err := client.HTTP01ChallengeResponse(chal.Token)
+			if err != nil {
+				return "", "", err
+			}
+			store.put(chal.Token, keyAuth)
+			defer store.delete(chal.Token)
+		case "dns-01":
+			if challengeCfg == nil || challengeCfg.DNSProvider == "" {
+				return "", "", fmt.Errorf("domain %s requests dns-01 but has no dns_provider configured", domain)
+			}
+			provider, err := dnsProviderFor(challengeCfg.DNSProvider, challengeCfg.DNSProviderConfig)
+			if err != nil {
+				return "", "", err
+			}
+			value, err := client.DNS01ChallengeRecord(chal.Token)
+			if err != nil {
+				return "", "", err
+			}
+			if err := provider.Present(ctx, domain, value); err != nil {
+				return "", "", fmt.Errorf("dns-01 Present failed: %w", err)
+			}
+			defer func() { _ = provider.CleanUp(ctx, domain, value) }()
+		default:
+			return "", "", fmt.Errorf("unsupported challenge type: %s", challengeType)
+		}
+
+		if _, err := client.Accept(ctx, chal); err != nil {
+			return "", "", fmt.Errorf("failed to accept challenge for %s: %w", domain, err)
+		}
+	}
+
+	if _, err := client.WaitOrder(ctx, order.URI); err != nil {
+		return "", "", fmt.Errorf("order for %s never became ready: %w", domain, err)
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	csr, err := buildCSR(leafKey, domain)
+	if err != nil {
+		return "", "", err
+	}
+
+	der, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to finalize order for %s: %w", domain, err)
+	}
+
+	certPEM = encodeCertChainPEM(der)
+	leafKeyDER, err := x509.MarshalECPrivateKey(leafKey)
+	if err != nil {
+		return "", "", err
+	}
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: leafKeyDER}))
+	return certPEM, keyPEM, nil
+}
+
+func buildCSR(key *ecdsa.PrivateKey, domain string) ([]byte, error) {
+	template := &x509.CertificateRequest{DNSNames: []string{domain}}
+	return x509.CreateCertificateRequest(rand.Reader, template, key)
+}
+
+func encodeCertChainPEM(der [][]byte) string {
+	var sb strings.Builder
+	for _, block := range der {
+		_ = pem.Encode(&sb, &pem.Block{Type: "CERTIFICATE", Bytes: block})
+	}
+	return sb.String()
+}
+
+// ACMERenewer scans for Managed certificates that are within RenewBeforeDays of expiry (or
+// TLS-enabled ingress domains lacking any TLSCertificate row at all) and (re)issues them through
+// issueCertificate, persisting the result via the same upsertTLSCertificate path manual uploads
+// use so BroadcastChangesToProxies fires identically either way.
+func (m *Manager) ACMERenewer() {
+	m.Wg.Add(1)
+	defer m.Wg.Done()
+
+	if m.Config.ACME == nil || !m.Config.ACME.Enabled {
+		return
+	}
+
+	if err := m.registerHTTP01IngressRule(); err != nil {
+		fmt.Printf("ACME: failed to register HTTP-01 ingress rule: %v\n", err)
+		return
+	}
+
+	store := newHTTPChallengeStore()
+	httpServer := m.acmeHTTP01Server(store)
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("ACME HTTP-01 server stopped: %v\n", err)
+		}
+	}()
+	go func() {
+		<-m.Context.Done()
+		_ = httpServer.Close()
+	}()
+
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.Context.Done():
+			return
+		case <-ticker.C:
+			// Re-register in case a config snapshot (see snapshot.go) removed it since the last
+			// tick: upsertListener/upsertBackend/upsertIngressRule are all idempotent, so this is
+			// a no-op when the rule is still in place.
+			if err := m.registerHTTP01IngressRule(); err != nil {
+				fmt.Printf("ACME: failed to re-register HTTP-01 ingress rule: %v\n", err)
+			}
+			m.renewDueCertificates(store)
+		}
+	}
+}
+
+func (m *Manager) renewBeforeDuration() time.Duration {
+	days := m.Config.ACME.RenewBeforeDays
+	if days <= 0 {
+		days = defaultACMERenewBeforeDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func (m *Manager) renewJitter() time.Duration {
+	minutes := m.Config.ACME.RenewJitterMinutes
+	if minutes <= 0 {
+		minutes = defaultACMERenewJitterMinutes
+	}
+	return time.Duration(mathrand.IntN(minutes)) * time.Minute
+}
+
+func (m *Manager) renewDueCertificates(store *httpChallengeStore) {
+	client, err := acmeClientFor(m.ReadWriteDB, m.Config.ACME.Email, m.Config.ACME.DirectoryURL)
+	if err != nil {
+		fmt.Printf("ACME: failed to load account: %v\n", err)
+		return
+	}
+
+	due, err := getManagedCertsDueForRenewal(m.ReadOnlyDB, time.Now().UTC().Add(m.renewBeforeDuration()))
+	if err != nil {
+		fmt.Printf("ACME: failed to list certs due for renewal: %v\n", err)
+		return
+	}
+
+	missing, err := domainsNeedingACMECert(m.ReadOnlyDB)
+	if err != nil {
+		fmt.Printf("ACME: failed to list domains needing certs: %v\n", err)
+		return
+	}
+
+	domains := make([]string, 0, len(due)+len(missing))
+	for _, cert := range due {
+		if !cert.IsWildcard {
+			domains = append(domains, cert.Domain)
+		}
+	}
+	domains = append(domains, missing...)
+
+	for _, domain := range uniqueSortedStrings(domains) {
+		// Stagger renewals across RenewJitterMinutes so certificates that all came due in the
+		// same tick don't all hit the CA at once.
+		select {
+		case <-time.After(m.renewJitter()):
+		case <-m.Context.Done():
+			return
+		}
+
+		certPEM, keyPEM, err := m.issueCertificateWithBackoff(domain, client, store)
+		if err != nil {
+			fmt.Printf("ACME: failed to issue certificate for %s: %v\n", domain, err)
+			continue
+		}
+
+		expiry, err := getCertExpiry(certPEM)
+		if err != nil {
+			fmt.Printf("ACME: issued invalid certificate for %s: %v\n", domain, err)
+			continue
+		}
+
+		if _, err := upsertTLSCertificate(m.ReadWriteDB, domain, false, certPEM, keyPEM, expiry, true); err != nil {
+			fmt.Printf("ACME: failed to persist renewed certificate for %s: %v\n", domain, err)
+			continue
+		}
+
+		m.BroadcastChangesToProxies(TypeURLTLSCertificates)
+	}
+}
+
+// issueCertificateWithBackoff runs issueCertificate, retrying on errors the CA flags as rate
+// limits: it waits for the Retry-After the CA asked for, or the next exponential backoff
+// interval if the CA didn't specify one. Any other error is returned immediately.
+func (m *Manager) issueCertificateWithBackoff(domain string, client *acme.Client, store *httpChallengeStore) (string, string, error) {
+	boff := backoff.NewExponentialBackOff()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		certPEM, keyPEM, err := m.issueCertificate(ctx, client, store, domain)
+		cancel()
+		if err == nil {
+			return certPEM, keyPEM, nil
+		}
+
+		wait, isRateLimit := acme.RateLimit(err)
+		if !isRateLimit {
+			return "", "", err
+		}
+		if wait <= 0 {
+			wait = boff.NextBackOff()
+		}
+		if wait == backoff.Stop {
+			return "", "", err
+		}
+
+		fmt.Printf("ACME: rate limited issuing certificate for %s, retrying in %s\n", domain, wait)
+		select {
+		case <-time.After(wait):
+		case <-m.Context.Done():
+			return "", "", m.Context.Err()
+		}
+	}
+}
@@ -0,0 +1,504 @@
+package main
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// defaultAdminAPIRequestTimeout bounds how long an upsert/delete call waits for processMessage to
+// commit before the handler gives up and returns 504, when AdminAPIConfig.RequestTimeoutSeconds
+// is left zero.
+const defaultAdminAPIRequestTimeout = 30 * time.Second
+
+// pendingCompletions lets AdminAPIServer's synchronous upsert/delete handlers block on a
+// RequestID and be woken the instant ProcessRequests commits that Message's result, instead of
+// polling the DB.
+type pendingCompletions struct {
+	mu sync.Mutex
+	m  map[string]chan *Message
+}
+
+func newPendingCompletions() *pendingCompletions {
+	return &pendingCompletions{m: make(map[string]chan *Message)}
+}
+
+// register must be called before the Message row backing requestID can possibly commit, so the
+// notify is never missed. The channel is buffered so notify never blocks, even if the caller has
+// already given up (timed out) by the time ProcessRequests gets to it.
+func (p *pendingCompletions) register(requestID string) chan *Message {
+	ch := make(chan *Message, 1)
+	p.mu.Lock()
+	p.m[requestID] = ch
+	p.mu.Unlock()
+	return ch
+}
+
+func (p *pendingCompletions) unregister(requestID string) {
+	p.mu.Lock()
+	delete(p.m, requestID)
+	p.mu.Unlock()
+}
+
+func (p *pendingCompletions) notify(msg *Message) {
+	p.mu.Lock()
+	ch, ok := p.m[msg.RequestID]
+	if ok {
+		delete(p.m, msg.RequestID)
+	}
+	p.mu.Unlock()
+	if ok {
+		ch <- msg
+	}
+}
+
+// adminAPIEventRoutes maps each admin API upsert/delete path to the NATS event name sharing its
+// validation and Message pipeline (see EventToRequestTypeMapping in handler.go).
+var adminAPIEventRoutes = map[string]string{
+	"/v1/tls_certificate/upsert":    "v1.tls_certificate.upsert",
+	"/v1/tls_certificate/delete":    "v1.tls_certificate.delete",
+	"/v1/ingress_rule/upsert":       "v1.ingress_rule.upsert",
+	"/v1/ingress_rule/delete":       "v1.ingress_rule.delete",
+	"/v1/http_redirect_rule/upsert": "v1.http_redirect_rule.upsert",
+	"/v1/http_redirect_rule/delete": "v1.http_redirect_rule.delete",
+}
+
+// adminAPIMetrics holds the admin API's own Prometheus collectors, registered against the shared
+// registry Manager.Metrics owns (see PipelineMetrics in pipelinemetrics.go) so /metrics reports
+// both under one scrape.
+type adminAPIMetrics struct {
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+}
+
+// newAdminAPIMetrics registers the admin API's own request counters/histogram into registry.
+func newAdminAPIMetrics(registry *prometheus.Registry) *adminAPIMetrics {
+	metrics := &adminAPIMetrics{
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vessel_admin_api_requests_total",
+			Help: "Total admin API requests, by route and outcome.",
+		}, []string{"route", "outcome"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "vessel_admin_api_request_duration_seconds",
+			Help: "Admin API request latency in seconds, by route.",
+		}, []string{"route"}),
+	}
+	registry.MustRegister(metrics.requestsTotal, metrics.requestDuration)
+	return metrics
+}
+
+func (a *adminAPIMetrics) observe(route string, outcome string, start time.Time) {
+	a.requestsTotal.WithLabelValues(route, outcome).Inc()
+	a.requestDuration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+}
+
+// AdminAPIServer brings up the HTTP/JSON admin API that proxies and operators can use as a
+// synchronous alternative to NATS (see Config.AdminAPI). It's a no-op if AdminAPI isn't enabled.
+func (m *Manager) AdminAPIServer() {
+	m.Wg.Add(1)
+	defer m.Wg.Done()
+
+	if m.Config.AdminAPI == nil || !m.Config.AdminAPI.Enabled {
+		return
+	}
+	cfg := m.Config.AdminAPI
+
+	listener, err := newAdminAPIListener(cfg)
+	if err != nil {
+		fmt.Printf("AdminAPI: failed to listen: %v\n", err)
+		return
+	}
+
+	tlsConfig, err := newAdminAPITLSConfig(cfg)
+	if err != nil {
+		fmt.Printf("AdminAPI: failed to configure TLS: %v\n", err)
+		_ = listener.Close()
+		return
+	}
+
+	server := &http.Server{Handler: m.newAdminAPIHandler(), TLSConfig: tlsConfig}
+
+	go func() {
+		<-m.Context.Done()
+		_ = server.Close()
+	}()
+
+	fmt.Printf("AdminAPI: listening on %s\n", listener.Addr())
+
+	var serveErr error
+	if tlsConfig != nil {
+		serveErr = server.ServeTLS(listener, "", "")
+	} else {
+		serveErr = server.Serve(listener)
+	}
+	if serveErr != nil && serveErr != http.ErrServerClosed {
+		fmt.Printf("AdminAPI server stopped: %v\n", serveErr)
+	}
+}
+
+// newAdminAPIListener binds to cfg.UnixSocketPath (preferred) or cfg.ListenAddr. A stale socket
+// file left behind by an unclean shutdown is removed first so binding doesn't fail.
+func newAdminAPIListener(cfg *AdminAPIConfig) (net.Listener, error) {
+	if cfg.UnixSocketPath != "" {
+		_ = os.Remove(cfg.UnixSocketPath)
+		return net.Listen("unix", cfg.UnixSocketPath)
+	}
+	addr := cfg.ListenAddr
+	if addr == "" {
+		addr = ":8443"
+	}
+	return net.Listen("tcp", addr)
+}
+
+// newAdminAPITLSConfig builds the server's TLS config from CertFile/KeyFile, additionally
+// requiring and verifying a client certificate against ClientCAFile (mTLS) when set. Returns
+// (nil, nil) when neither CertFile nor KeyFile is set, meaning the API is served in plaintext.
+func newAdminAPITLSConfig(cfg *AdminAPIConfig) (*tls.Config, error) {
+	if cfg.CertFile == "" && cfg.KeyFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cert/key: %w", err)
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	if cfg.ClientCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client_ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in client_ca_file %q", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+func (m *Manager) newAdminAPIHandler() http.Handler {
+	metrics := newAdminAPIMetrics(m.Metrics.Registry)
+	mux := http.NewServeMux()
+
+	for path, event := range adminAPIEventRoutes {
+		mux.HandleFunc(path, m.adminAPIEventHandler(event, metrics))
+	}
+	mux.HandleFunc("/v1/events/", m.adminAPIGenericEventHandler(metrics))
+
+	mux.HandleFunc("/v1/listeners", m.adminAPIListHandler("/v1/listeners", metrics, func() (interface{}, error) {
+		return listListeners(m.ReadOnlyDB)
+	}))
+	mux.HandleFunc("/v1/backends", m.adminAPIListHandler("/v1/backends", metrics, func() (interface{}, error) {
+		return listBackends(m.ReadOnlyDB)
+	}))
+	mux.HandleFunc("/v1/ingress_rules", m.adminAPIListHandler("/v1/ingress_rules", metrics, func() (interface{}, error) {
+		return listIngressRules(m.ReadOnlyDB)
+	}))
+	mux.HandleFunc("/v1/ingress-rules", m.adminAPIListHandler("/v1/ingress-rules", metrics, func() (interface{}, error) {
+		return listIngressRules(m.ReadOnlyDB)
+	}))
+	mux.HandleFunc("/v1/http_redirect_rules", m.adminAPIListHandler("/v1/http_redirect_rules", metrics, func() (interface{}, error) {
+		return listHTTPRedirectRules(m.ReadOnlyDB)
+	}))
+	mux.HandleFunc("/v1/redirects", m.adminAPIListHandler("/v1/redirects", metrics, func() (interface{}, error) {
+		return listHTTPRedirectRules(m.ReadOnlyDB)
+	}))
+	mux.HandleFunc("/v1/certs", m.adminAPIListHandler("/v1/certs", metrics, func() (interface{}, error) {
+		return listTLSCertificates(m.ReadOnlyDB)
+	}))
+	mux.HandleFunc("/v1/ingress_rule_backends", m.adminAPIListHandler("/v1/ingress_rule_backends", metrics, func() (interface{}, error) {
+		links, err := listIngressRuleBackends(m.ReadOnlyDB)
+		if err != nil {
+			return nil, err
+		}
+		views := make([]ingressRuleBackendView, 0, len(links))
+		for _, link := range links {
+			views = append(views, ingressRuleBackendView{
+				IngressRuleBackend: link,
+				Healthy:            m.PoolHealth.Healthy(link.ID),
+			})
+		}
+		return views, nil
+	}))
+	mux.HandleFunc("/v1/messages", m.adminAPIMessagesHandler(metrics))
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		sqlDB, err := m.ReadOnlyDB.DB()
+		if err != nil || sqlDB.Ping() != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("not ready"))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ready"))
+	})
+	mux.Handle("/metrics", promhttp.HandlerFor(m.Metrics.Registry, promhttp.HandlerOpts{}))
+
+	// Operational visibility for whoever is driving the proxy fleet directly through this API --
+	// exported counters and pprof profiles, same as any other long-running Go service.
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return m.adminAPIAuthMiddleware(mux)
+}
+
+// adminAPIAuthMiddleware enforces cfg.BearerToken if set. mTLS (when ClientCAFile is configured)
+// is enforced by the TLS handshake itself via tls.RequireAndVerifyClientCert, before a request
+// ever reaches here. /healthz and /metrics are exempt so monitoring doesn't need the token.
+func (m *Manager) adminAPIAuthMiddleware(next http.Handler) http.Handler {
+	token := m.Config.AdminAPI.BearerToken
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && r.URL.Path != "/healthz" && r.URL.Path != "/metrics" {
+			const prefix = "Bearer "
+			header := r.Header.Get("Authorization")
+			if !strings.HasPrefix(header, prefix) ||
+				subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(header, prefix)), []byte(token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// adminAPIEventHandler wraps handleAdminAPIEvent for one of the dedicated per-event routes (see
+// adminAPIEventRoutes), where the event name is fixed at registration time.
+func (m *Manager) adminAPIEventHandler(event string, metrics *adminAPIMetrics) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m.handleAdminAPIEvent(w, r, event, metrics)
+	}
+}
+
+// adminAPIGenericEventHandler serves POST /v1/events/{event}, dispatching by name to any event in
+// EventToRequestTypeMapping. Unlike the dedicated per-event routes, this one route automatically
+// covers every event type handler.go knows about, including ones added after this file was.
+func (m *Manager) adminAPIGenericEventHandler(metrics *adminAPIMetrics) http.HandlerFunc {
+	const routeLabel = "/v1/events/{event}"
+	return func(w http.ResponseWriter, r *http.Request) {
+		event := strings.TrimPrefix(r.URL.Path, "/v1/events/")
+		if event == "" || strings.Contains(event, "/") {
+			http.Error(w, "missing or invalid event name in path", http.StatusBadRequest)
+			metrics.observe(routeLabel, "bad_request", time.Now())
+			return
+		}
+		if _, ok := EventToRequestTypeMapping[event]; !ok {
+			http.Error(w, fmt.Sprintf("unknown event: %s", event), http.StatusNotFound)
+			metrics.observe(routeLabel, "bad_request", time.Now())
+			return
+		}
+		m.handleAdminAPIEvent(w, r, event, metrics)
+	}
+}
+
+// handleAdminAPIEvent runs an admin API event request through the exact same parseEvent ->
+// Message insert -> processMessage pipeline NATS events use, then blocks on Manager.Completions
+// until ProcessRequests commits a result (or the configured timeout elapses), giving the caller a
+// synchronous ResponsePayloadV1 instead of a bare "queued".
+func (m *Manager) handleAdminAPIEvent(w http.ResponseWriter, r *http.Request, event string, metrics *adminAPIMetrics) {
+	start := time.Now()
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		metrics.observe(event, "bad_request", start)
+		return
+	}
+
+	// In clustered mode, only the leader's ProcessRequests consumes queued messages (see
+	// manager.go); a follower enqueueing locally would just hang until the request timeout. Tell
+	// the caller where the leader is instead of accepting a write we can't service.
+	if m.Cluster != nil && !m.Cluster.IsLeader() {
+		leaderAddr := m.Cluster.LeaderRaftAddr()
+		if leaderAddr == "" {
+			http.Error(w, "cluster has no leader right now", http.StatusServiceUnavailable)
+		} else {
+			http.Error(w, fmt.Sprintf("not the leader; leader raft address is %s", leaderAddr), http.StatusMisdirectedRequest)
+		}
+		metrics.observe(event, "not_leader", start)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read body: %v", err), http.StatusBadRequest)
+		metrics.observe(event, "bad_request", start)
+		return
+	}
+
+	isParsed, requestID, requestedAt, request, err := parseEvent(event, body)
+	if !isParsed || err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		metrics.observe(event, "bad_request", start)
+		return
+	}
+	requestPayload, err := json.Marshal(request)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to marshal request: %v", err), http.StatusBadRequest)
+		metrics.observe(event, "bad_request", start)
+		return
+	}
+
+	// Register before enqueueing so there's no window where ProcessRequests could commit and
+	// notify before we start waiting on the channel.
+	completion := m.Completions.register(requestID)
+
+	msg, isNew, err := m.enqueueMessage(event, requestID, *requestedAt, requestPayload)
+	if err != nil {
+		m.Completions.unregister(requestID)
+		http.Error(w, fmt.Sprintf("failed to queue request: %v", err), http.StatusInternalServerError)
+		metrics.observe(event, "error", start)
+		return
+	}
+	m.Metrics.recordReceived(event)
+
+	if !isNew && msg.Processed {
+		m.Completions.unregister(requestID)
+		writeAdminAPIResponse(w, msg)
+		metrics.observe(event, "duplicate", start)
+		return
+	}
+
+	timeout := secondsOrDefault(m.Config.AdminAPI.RequestTimeoutSeconds, defaultAdminAPIRequestTimeout)
+	select {
+	case result := <-completion:
+		writeAdminAPIResponse(w, result)
+		if result.Success {
+			metrics.observe(event, "success", start)
+		} else {
+			metrics.observe(event, "failed", start)
+		}
+	case <-time.After(timeout):
+		m.Completions.unregister(requestID)
+		http.Error(w, "timed out waiting for request to process", http.StatusGatewayTimeout)
+		metrics.observe(event, "timeout", start)
+	case <-r.Context().Done():
+		m.Completions.unregister(requestID)
+	}
+}
+
+// writeAdminAPIResponse writes msg's outcome as the HTTP response: a ResponsePayloadV1 (the exact
+// shape NATS replies with) once processed, or a bare "queued" marker for the rare case a
+// concurrent duplicate request is still in flight.
+func writeAdminAPIResponse(w http.ResponseWriter, msg *Message) {
+	w.Header().Set("Content-Type", "application/json")
+	if !msg.Processed {
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(map[string]string{"request_id": msg.RequestID, "status": "queued"})
+		return
+	}
+	if msg.Success {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	_ = json.NewEncoder(w).Encode(messageToResponsePayload(msg))
+}
+
+// adminAPIMessagesHandler serves GET /v1/messages, the read-only view onto the Message queue used
+// for operator troubleshooting. ?pending=true restricts to unprocessed rows, ?failed=true to
+// processed-but-unsuccessful rows (pending wins if both are set), ?since=<RFC3339 timestamp>
+// restricts to rows queued at or after that time, and ?limit=N caps the row count (default 100).
+func (m *Manager) adminAPIMessagesHandler(metrics *adminAPIMetrics) http.HandlerFunc {
+	const route = "/v1/messages"
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			metrics.observe(route, "bad_request", start)
+			return
+		}
+
+		pendingOnly := r.URL.Query().Get("pending") == "true"
+		failedOnly := r.URL.Query().Get("failed") == "true"
+		limit := 100
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			if parsed, err := parsePositiveInt(raw); err == nil {
+				limit = parsed
+			}
+		}
+		var since *time.Time
+		if raw := r.URL.Query().Get("since"); raw != "" {
+			if parsed, err := time.Parse(time.RFC3339, raw); err == nil {
+				since = &parsed
+			}
+		}
+
+		rows, err := listMessages(m.ReadOnlyDB, pendingOnly, failedOnly, since, limit)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to list messages: %v", err), http.StatusInternalServerError)
+			metrics.observe(route, "error", start)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(rows); err != nil {
+			fmt.Printf("AdminAPI: failed to encode response for %s: %v\n", route, err)
+		}
+		metrics.observe(route, "success", start)
+	}
+}
+
+// ingressRuleBackendView annotates an IngressRuleBackend with its live PoolHealthTracker state,
+// for the /v1/ingress_rule_backends listing -- Healthy isn't a DB column, so it can't just be
+// Preloaded like Backend/HealthCheck.
+type ingressRuleBackendView struct {
+	IngressRuleBackend
+	Healthy bool `json:"healthy"`
+}
+
+// adminAPIListHandler wraps a read-only listing function (e.g. listListeners) as a GET-only JSON
+// endpoint, sharing request counting/timing across all the listing routes.
+func (m *Manager) adminAPIListHandler(route string, metrics *adminAPIMetrics, list func() (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			metrics.observe(route, "bad_request", start)
+			return
+		}
+		rows, err := list()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to list: %v", err), http.StatusInternalServerError)
+			metrics.observe(route, "error", start)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(rows); err != nil {
+			fmt.Printf("AdminAPI: failed to encode response for %s: %v\n", route, err)
+		}
+		metrics.observe(route, "success", start)
+	}
+}
+
+func parsePositiveInt(raw string) (int, error) {
+	var value int
+	_, err := fmt.Sscanf(raw, "%d", &value)
+	if err != nil {
+		return 0, err
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("value must be positive")
+	}
+	return value, nil
+}
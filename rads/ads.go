@@ -0,0 +1,372 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// ads.go implements the gRPC Aggregated Discovery Service (ADS) that proxy sidecars stream config
+// from, modeled on Envoy's xDS delta protocol: one bidirectional StreamAggregatedResources call
+// per proxy, subscribing to whichever resource types it cares about, with each push carrying a
+// monotonically increasing version and a nonce the proxy must ack (or nack) before the server
+// sends that type again. This is the real implementation behind BroadcastChangesToProxies /
+// ListenForBroadcastChangesToProxies, which used to just flip an atomic bool and do nothing.
+//
+// There's no .proto/protoc in this repo's build yet, so the wire messages below are plain Go
+// structs carried over grpc using the JSON codec registered in init() -- the part xDS actually
+// needs is the streaming + versioned-ack discipline, not the bytes on the wire.
+
+const (
+	adsPushInterval = 500 * time.Millisecond
+)
+
+// Resource type URLs identify which kind of config object a DiscoveryRequest/DiscoveryResponse
+// carries, one per resource kind ADS can stream.
+const (
+	TypeURLListeners         = "vessel.config.v1.Listener"
+	TypeURLBackends          = "vessel.config.v1.Backend"
+	TypeURLIngressRules      = "vessel.config.v1.IngressRule"
+	TypeURLTLSCertificates   = "vessel.config.v1.TLSCertificate"
+	TypeURLHTTPRedirectRules = "vessel.config.v1.HTTPRedirectRule"
+)
+
+// adsResourceTypeURLs lists every resource type ADS can stream -- exactly the TypeURL* constants
+// above -- and is what resourceVersionTracker is keyed by.
+var adsResourceTypeURLs = []string{
+	TypeURLListeners,
+	TypeURLBackends,
+	TypeURLIngressRules,
+	TypeURLTLSCertificates,
+	TypeURLHTTPRedirectRules,
+}
+
+// resourceVersionTracker holds one pending-change flag and one version counter per ADS resource
+// type, so bumping one type's version (Manager.ListenForBroadcastChangesToProxies) never forces
+// an open stream to resend a type nothing changed in.
+type resourceVersionTracker struct {
+	pending map[string]*atomic.Bool
+	version map[string]*atomic.Int64
+}
+
+func newResourceVersionTracker() *resourceVersionTracker {
+	t := &resourceVersionTracker{
+		pending: make(map[string]*atomic.Bool, len(adsResourceTypeURLs)),
+		version: make(map[string]*atomic.Int64, len(adsResourceTypeURLs)),
+	}
+	for _, typeURL := range adsResourceTypeURLs {
+		t.pending[typeURL] = &atomic.Bool{}
+		t.version[typeURL] = &atomic.Int64{}
+	}
+	return t
+}
+
+// versionString returns typeURL's current version, formatted the same way DiscoveryResponse's
+// VersionInfo is everywhere else in this file.
+func (t *resourceVersionTracker) versionString(typeURL string) string {
+	return strconv.FormatInt(t.version[typeURL].Load(), 10)
+}
+
+// DiscoveryRequest is sent by a proxy both to subscribe to a resource type and to ack/nack the
+// last response it received for that type.
+type DiscoveryRequest struct {
+	TypeURL       string `json:"type_url"`
+	VersionInfo   string `json:"version_info"`
+	ResponseNonce string `json:"response_nonce"`
+	ErrorDetail   string `json:"error_detail,omitempty"`
+}
+
+// DiscoveryResponse carries a full snapshot of one resource type as of VersionInfo. Nonce
+// identifies this exact response so the proxy's next DiscoveryRequest can ack/nack it.
+type DiscoveryResponse struct {
+	TypeURL     string            `json:"type_url"`
+	VersionInfo string            `json:"version_info"`
+	Nonce       string            `json:"nonce"`
+	Resources   []json.RawMessage `json:"resources"`
+}
+
+func init() {
+	encoding.RegisterCodec(adsJSONCodec{})
+}
+
+// adsJSONCodec overrides grpc-go's default "proto" codec so DiscoveryRequest/DiscoveryResponse
+// can travel over the wire without generated protobuf types.
+type adsJSONCodec struct{}
+
+func (adsJSONCodec) Name() string                               { return "proto" }
+func (adsJSONCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (adsJSONCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// AggregatedDiscoveryServiceServer is the hand-rolled equivalent of what protoc-gen-go-grpc would
+// generate for a single bidi-streaming RPC named StreamAggregatedResources.
+type AggregatedDiscoveryServiceServer interface {
+	StreamAggregatedResources(grpc.BidiStreamingServer[DiscoveryRequest, DiscoveryResponse]) error
+}
+
+var aggregatedDiscoveryServiceDesc = grpc.ServiceDesc{
+	ServiceName: "vessel.ads.v1.AggregatedDiscoveryService",
+	HandlerType: (*AggregatedDiscoveryServiceServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamAggregatedResources",
+			Handler:       streamAggregatedResourcesHandler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "ads.go",
+}
+
+func streamAggregatedResourcesHandler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AggregatedDiscoveryServiceServer).StreamAggregatedResources(
+		&grpc.GenericServerStream[DiscoveryRequest, DiscoveryResponse]{ServerStream: stream},
+	)
+}
+
+// retryingServerStream wraps a grpc.ServerStream so a transient Unavailable error from SendMsg is
+// retried a couple of times with backoff before it's allowed to tear down the stream -- covers a
+// sidecar's connection being momentarily recycled without masking a genuinely dead peer.
+type retryingServerStream struct {
+	grpc.ServerStream
+}
+
+var adsSendRetryBackoff = []time.Duration{100 * time.Millisecond, 500 * time.Millisecond}
+
+func (s *retryingServerStream) SendMsg(m interface{}) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = s.ServerStream.SendMsg(m)
+		if err == nil || status.Code(err) != codes.Unavailable || attempt >= len(adsSendRetryBackoff) {
+			return err
+		}
+		time.Sleep(adsSendRetryBackoff[attempt])
+	}
+}
+
+// retryUnavailableInterceptor is installed on the ADS gRPC server so every stream gets the
+// SendMsg retry behavior above without each handler having to wrap its own stream.
+func retryUnavailableInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &retryingServerStream{ServerStream: ss})
+}
+
+// adsServer implements AggregatedDiscoveryServiceServer against a Manager's ReadOnlyDB.
+type adsServer struct {
+	manager *Manager
+}
+
+// StreamAggregatedResources serves one proxy connection for its lifetime: a background goroutine
+// drains DiscoveryRequests (subscriptions + acks/nacks) into the per-type cursor below, while the
+// main loop pushes a fresh snapshot of each subscribed type whenever that type's own version (see
+// resourceVersionTracker) has moved past what that type's cursor last had acked -- so a
+// reconnecting proxy that acks an old version only ever receives what changed since then, and a
+// change to one resource type never forces a resend of the others.
+func (s *adsServer) StreamAggregatedResources(stream grpc.BidiStreamingServer[DiscoveryRequest, DiscoveryResponse]) error {
+	m := s.manager
+
+	type cursor struct {
+		subscribed   bool
+		ackedVersion string // version_info the proxy has confirmed applying
+		pendingNonce string // nonce of an in-flight, not-yet-acked response; "" if none
+	}
+	cursors := make(map[string]*cursor)
+	var mu sync.Mutex
+
+	recvErrCh := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				recvErrCh <- err
+				return
+			}
+
+			mu.Lock()
+			c, ok := cursors[req.TypeURL]
+			if !ok {
+				c = &cursor{}
+				cursors[req.TypeURL] = c
+			}
+			c.subscribed = true
+
+			switch {
+			case req.ErrorDetail != "":
+				log.Printf("ads: proxy nacked %s at nonce %s: %s", req.TypeURL, req.ResponseNonce, req.ErrorDetail)
+				if req.ResponseNonce == c.pendingNonce {
+					c.pendingNonce = "" // retry immediately on the next push tick
+				}
+			case req.ResponseNonce == c.pendingNonce:
+				c.ackedVersion = req.VersionInfo
+				c.pendingNonce = ""
+			}
+			mu.Unlock()
+		}
+	}()
+
+	ticker := time.NewTicker(adsPushInterval)
+	defer ticker.Stop()
+
+	type pushTarget struct {
+		typeURL string
+		version string
+	}
+
+	nonceSeq := 0
+	push := func() error {
+		mu.Lock()
+		targets := make([]pushTarget, 0, len(cursors))
+		for typeURL, c := range cursors {
+			version := m.resourceVersions.versionString(typeURL)
+			if c.subscribed && c.pendingNonce == "" && c.ackedVersion != version {
+				targets = append(targets, pushTarget{typeURL: typeURL, version: version})
+			}
+		}
+		mu.Unlock()
+
+		for _, target := range targets {
+			resources, err := snapshotResourcesForType(m.ReadOnlyDB, target.typeURL)
+			if err != nil {
+				return fmt.Errorf("failed to snapshot %s: %w", target.typeURL, err)
+			}
+
+			nonceSeq++
+			nonce := fmt.Sprintf("%s-%d", target.version, nonceSeq)
+
+			mu.Lock()
+			cursors[target.typeURL].pendingNonce = nonce
+			mu.Unlock()
+
+			if err := stream.Send(&DiscoveryResponse{
+				TypeURL:     target.typeURL,
+				VersionInfo: target.version,
+				Nonce:       nonce,
+				Resources:   resources,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for {
+		select {
+		case err := <-recvErrCh:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-m.Context.Done():
+			return nil
+		case <-ticker.C:
+			if err := push(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// snapshotResourcesForType loads every row of the given resource type and marshals each to JSON,
+// for embedding in a DiscoveryResponse.
+func snapshotResourcesForType(db *gorm.DB, typeURL string) ([]json.RawMessage, error) {
+	switch typeURL {
+	case TypeURLListeners:
+		var rows []Listener
+		if err := db.Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		return marshalResources(rows)
+	case TypeURLBackends:
+		var rows []Backend
+		if err := db.Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		return marshalResources(rows)
+	case TypeURLIngressRules:
+		var rows []IngressRule
+		if err := db.Preload("Backends").Preload("Backends.Backend").Preload("Backends.HealthCheck").
+			Preload("Middlewares").Preload("Middlewares.Middleware").
+			Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		return marshalResources(rows)
+	case TypeURLTLSCertificates:
+		var rows []TLSCertificate
+		// Exclude rows still awaiting ACME issuance (see TLSCertificateACMERequestV1.Process):
+		// proxies should never see a TLSCertificate resource with no cert material.
+		if err := db.Where("cert <> ''").Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		return marshalResources(rows)
+	case TypeURLHTTPRedirectRules:
+		var rows []HTTPRedirectRule
+		if err := db.Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		return marshalResources(rows)
+	default:
+		return nil, fmt.Errorf("unknown resource type_url: %s", typeURL)
+	}
+}
+
+// marshalResources marshals each element of rows to its own json.RawMessage.
+func marshalResources[T any](rows []T) ([]json.RawMessage, error) {
+	resources := make([]json.RawMessage, 0, len(rows))
+	for i := range rows {
+		raw, err := json.Marshal(rows[i])
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, raw)
+	}
+	return resources, nil
+}
+
+// ADSServer brings up the gRPC listener proxies connect to for StreamAggregatedResources. It's a
+// no-op if Config.ADS isn't set, so deployments that only want NATS-delivered events don't need
+// to open another port.
+func (m *Manager) ADSServer() {
+	m.Wg.Add(1)
+	defer m.Wg.Done()
+
+	if m.Config.ADS == nil {
+		return
+	}
+
+	listenAddr := m.Config.ADS.ListenAddr
+	if listenAddr == "" {
+		listenAddr = ":18443"
+	}
+
+	lis, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		fmt.Printf("ADS: failed to listen on %s: %v\n", listenAddr, err)
+		return
+	}
+
+	grpcServer := grpc.NewServer(grpc.StreamInterceptor(retryUnavailableInterceptor))
+	grpcServer.RegisterService(&aggregatedDiscoveryServiceDesc, &adsServer{manager: m})
+
+	go func() {
+		<-m.Context.Done()
+		grpcServer.GracefulStop()
+	}()
+
+	fmt.Printf("ADS: listening for StreamAggregatedResources on %s\n", listenAddr)
+	if err := grpcServer.Serve(lis); err != nil {
+		fmt.Printf("ADS: gRPC server stopped: %v\n", err)
+	}
+}
@@ -8,14 +8,142 @@ import (
 )
 
 type Config struct {
-	AgentID          string     `yaml:"agent_id"`
-	DatabaseFilePath string     `yaml:"database_file_path"`
-	NatsConfig       NatsConfig `yaml:"nats_config"`
+	AgentID          string      `yaml:"agent_id"`
+	DatabaseFilePath string      `yaml:"database_file_path"`
+	NatsConfig       NatsConfig  `yaml:"nats_config"`
+	ACME             *ACMEConfig `yaml:"acme"`
+
+	// DevCA brings up a persistent self-signed root CA that mints leaf certificates on demand
+	// for ingress domains lacking a real TLSCertificate row. Intended for local development only.
+	DevCA bool `yaml:"dev_ca"`
+
+	// ADS enables the gRPC Aggregated Discovery Service proxies stream config from (see ads.go).
+	// Leave it unset to disable the gRPC listener and rely on NATS-delivered events only.
+	ADS *ADSConfig `yaml:"ads"`
+
+	// K8sGateway enables the Kubernetes Gateway API provider (see k8sgateway.go), an alternative
+	// to NATS-delivered events for clusters that manage ingress via Gateway/HTTPRoute/TCPRoute/
+	// TLSRoute objects.
+	K8sGateway *K8sGatewayConfig `yaml:"k8s_gateway"`
+
+	// AdminAPI enables the synchronous HTTP/JSON admin API (see adminapi.go), an alternative to
+	// NATS for applying the same event types NATS accepts, plus read-only listing and metrics.
+	AdminAPI *AdminAPIConfig `yaml:"admin_api"`
+
+	// Cluster enables Raft-replicated HA clustering (see clusterstore.go). Leave it unset to run
+	// as a single standalone node, each with its own independent state, as before.
+	Cluster *ClusterConfig `yaml:"cluster"`
+}
+
+// ADSConfig configures the gRPC listener for StreamAggregatedResources.
+type ADSConfig struct {
+	ListenAddr string `yaml:"listen_addr"`
+}
+
+// K8sGatewayConfig configures the Kubernetes Gateway API provider.
+type K8sGatewayConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Kubeconfig is the path to a kubeconfig file. Leave it empty to use in-cluster
+	// credentials (the usual case when rads itself runs inside the cluster).
+	Kubeconfig string `yaml:"kubeconfig"`
+
+	// ControllerName is written as the ControllerName of every Gateway/Route status condition
+	// this provider sets. Defaults to "vessel.io/gateway-controller"; only needs overriding when
+	// more than one Gateway controller watches the same cluster.
+	ControllerName string `yaml:"controller_name"`
+}
+
+// ClusterConfig configures Raft-backed HA clustering (see clusterstore.go). Every node runs its
+// own Raft FSM applying the same mutations to its own GORM SQLite state, kept in sync by the
+// leader's replicated log instead of each node racing independently on the same NATS stream.
+type ClusterConfig struct {
+	// NodeID must be unique within the cluster; it's how other nodes and raft.Configuration
+	// address this one. Defaults to AgentID when left empty.
+	NodeID string `yaml:"node_id"`
+
+	// RaftBindAddr is the TCP address (host:port) the Raft transport listens on for
+	// AppendEntries/RequestVote/InstallSnapshot RPCs from other nodes.
+	RaftBindAddr string `yaml:"raft_bind_addr"`
+
+	// RaftDataDir stores the BoltDB log/stable store and snapshot files. Must be unique per node
+	// and must survive restarts for the node to rejoin without a full snapshot transfer.
+	RaftDataDir string `yaml:"raft_data_dir"`
+
+	// Bootstrap brings this node up as a single-voter cluster of one on first start, before any
+	// other node has joined it. Only ever set this on the very first node of a brand-new cluster
+	// -- setting it on a node joining an existing cluster would fork the log.
+	Bootstrap bool `yaml:"bootstrap"`
+
+	// APIListenAddr is where POST /cluster/join, POST /cluster/remove, and GET /cluster/status
+	// are served (see ClusterAPIServer).
+	APIListenAddr string `yaml:"api_listen_addr"`
 }
 
 type NatsConfig struct {
 	Host string `yaml:"host"`
 	Port int    `yaml:"port"`
+
+	// RetryInitialIntervalMs/RetryMaxIntervalMs/RetryMaxElapsedMinutes tune the exponential
+	// backoff ListenToStream and SendResponsesToQueue use while reconnecting to NATS after a
+	// failure. Zero values fall back to the defaultNats* constants in manager.go.
+	RetryInitialIntervalMs int `yaml:"retry_initial_interval_ms"`
+	RetryMaxIntervalMs     int `yaml:"retry_max_interval_ms"`
+	RetryMaxElapsedMinutes int `yaml:"retry_max_elapsed_minutes"`
+
+	// PublishBatchSize caps how many processed-but-unreplied Message rows SendResponsesToQueue
+	// fetches and publishes via PublishAsync per round. Defaults to defaultNatsPublishBatchSize
+	// when left zero.
+	PublishBatchSize int `yaml:"publish_batch_size"`
+}
+
+// ACMEConfig enables the built-in ACME renewer (see acme.go). Leave it unset to manage
+// TLSCertificate rows purely through TLSCertificateUpsertV1.
+type ACMEConfig struct {
+	Enabled      bool   `yaml:"enabled"`
+	Email        string `yaml:"email"`
+	DirectoryURL string `yaml:"directory_url"`
+
+	// RenewBeforeDays is how far ahead of ExpiresAt a managed certificate is renewed. Defaults
+	// to 30 when left zero.
+	RenewBeforeDays int `yaml:"renew_before_days"`
+
+	// RenewJitterMinutes spreads renewals of certificates that all came due at once across a
+	// random window, to avoid every domain hitting the ACME CA in the same tick. Defaults to 30
+	// when left zero.
+	RenewJitterMinutes int `yaml:"renew_jitter_minutes"`
+
+	// ChallengeListenAddr is where the agent's own HTTP-01 responder listens; an IngressRule on
+	// port 80 (registered through upsertListener, like any other ingress rule) forwards
+	// /.well-known/acme-challenge/ traffic here. Defaults to "127.0.0.1:9080".
+	ChallengeListenAddr string `yaml:"challenge_listen_addr"`
+}
+
+// AdminAPIConfig configures the HTTP/JSON admin API (see adminapi.go).
+type AdminAPIConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// ListenAddr is a TCP address (e.g. "0.0.0.0:8443") to listen on. Leave it empty and set
+	// UnixSocketPath instead to bind to a Unix domain socket for local-only admin tools.
+	ListenAddr string `yaml:"listen_addr"`
+
+	// UnixSocketPath binds the API to a Unix domain socket instead of TCP. Takes precedence over
+	// ListenAddr when both are set.
+	UnixSocketPath string `yaml:"unix_socket_path"`
+
+	// BearerToken, when set, is required as "Authorization: Bearer <token>" on every request.
+	BearerToken string `yaml:"bearer_token"`
+
+	// CertFile/KeyFile enable HTTPS. ClientCAFile additionally requires and verifies a client
+	// certificate signed by that CA, i.e. mTLS. All three are optional and independent of
+	// BearerToken -- set both to require both.
+	CertFile     string `yaml:"cert_file"`
+	KeyFile      string `yaml:"key_file"`
+	ClientCAFile string `yaml:"client_ca_file"`
+
+	// RequestTimeoutSeconds bounds how long an upsert/delete call waits for processMessage to
+	// reply before returning 504. Defaults to defaultAdminAPIRequestTimeout when left zero.
+	RequestTimeoutSeconds int `yaml:"request_timeout_seconds"`
 }
 
 func loadConfig() (*Config, error) {
@@ -6,6 +6,8 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"slices"
+	"strings"
+	"time"
 )
 
 func getTLSCertificateID(domain string, isWildCard bool) string {
@@ -36,6 +38,88 @@ func getTLSCertByID(db *gorm.DB, id string) (*TLSCertificate, error) {
 	return &cert, nil
 }
 
+// upsertTLSCertificate creates or updates the TLSCertificate row for a domain, transactionally
+// with respect to whatever transaction `db` represents. Both the manual upload path
+// (TLSCertificateUpsertV1) and the ACME issuance/renewal path funnel through here so that
+// consumers of the table never observe a difference between the two origins.
+func upsertTLSCertificate(db *gorm.DB, domain string, isWildcard bool, cert string, key string, expiresAt time.Time, managed bool) (*TLSCertificate, error) {
+	id := getTLSCertificateID(domain, isWildcard)
+
+	isExist, err := isTLSCertificateExist(db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &TLSCertificate{
+		ID:         id,
+		Domain:     domain,
+		IsWildcard: isWildcard,
+		Cert:       cert,
+		Key:        key,
+		ExpiresAt:  expiresAt,
+		Managed:    managed,
+	}
+
+	if isExist {
+		err = db.Save(record).Error
+	} else {
+		err = db.Create(record).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// getManagedCertsDueForRenewal returns every Managed TLSCertificate row that's either missing
+// cert material or expires before the given cutoff, used by the ACME renewer to find
+// certificates due for (re)issuance. Non-managed (manually uploaded) rows are never touched.
+func getManagedCertsDueForRenewal(db *gorm.DB, cutoff time.Time) ([]TLSCertificate, error) {
+	var certs []TLSCertificate
+	err := db.Where("managed = ? AND (cert = ? OR expires_at < ?)", true, "", cutoff).Find(&certs).Error
+	if err != nil {
+		return nil, err
+	}
+	return certs, nil
+}
+
+// getMessageByEventAndRequestID looks up a Message row by its dedup key (event + RequestID),
+// returning (nil, nil) when no such row exists. Shared by Manager.enqueueMessage so NATS and the
+// admin API (adminapi.go) dedupe identically.
+func getMessageByEventAndRequestID(db *gorm.DB, event string, requestID string) (*Message, error) {
+	var msg Message
+	err := db.Where("event = ? AND request_id = ?", event, requestID).First(&msg).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// listMessages returns Message rows ordered newest-first, for the admin API's read-only
+// /v1/messages endpoint. pendingOnly restricts to unprocessed rows, failedOnly to
+// processed-but-unsuccessful rows; pendingOnly wins if both are set. limit <= 0 means unbounded.
+func listMessages(db *gorm.DB, pendingOnly bool, failedOnly bool, since *time.Time, limit int) ([]Message, error) {
+	query := db.Order("queued_at desc")
+	switch {
+	case pendingOnly:
+		query = query.Where("processed = ?", false)
+	case failedOnly:
+		query = query.Where("processed = ? AND success = ?", true, false)
+	}
+	if since != nil {
+		query = query.Where("queued_at >= ?", *since)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var rows []Message
+	err := query.Find(&rows).Error
+	return rows, err
+}
+
 func isListenerExist(db *gorm.DB, id string) (bool, error) {
 	var listener Listener
 	err := db.Where("id = ?", id).First(&listener).Error
@@ -61,6 +145,13 @@ func getListenerID(bindIP string, port int) string {
 	return fmt.Sprintf("%s:%d", bindIP, port)
 }
 
+// listListeners returns every Listener row, for the admin API's read-only listing endpoints.
+func listListeners(db *gorm.DB) ([]Listener, error) {
+	var rows []Listener
+	err := db.Order("port asc").Find(&rows).Error
+	return rows, err
+}
+
 func upsertListener(db *gorm.DB, bindIP string, port int, protocol ProtocolType, isTLS bool) (*Listener, error) {
 	id := getListenerID(bindIP, port)
 
@@ -122,7 +213,29 @@ func findBackend(db *gorm.DB, resolverType BackendResolverType, dnsResolver stri
 	return &backend, nil
 }
 
-func upsertBackend(db *gorm.DB, resolverType BackendResolverType, dnsResolver string, hosts []string, port int, isTLS bool, sniDomain string) (*Backend, error) {
+// upsertBackend finds or creates the Backend matching the given resolver/host identity, and
+// (re)applies the mutable LB/health-check/DNS-over-TLS-or-HTTPS config on top -- those fields
+// don't participate in the identity lookup, so changing them on an otherwise-unchanged backend
+// updates the existing row rather than forking a duplicate one. Zero values fall back to the same
+// defaults as the Backend struct tags. dnsServerName/dnsBootstrapIPs/dnsCABundle only apply when
+// resolverType is DOT_RESOLVER or DOH_RESOLVER.
+func upsertBackend(db *gorm.DB, resolverType BackendResolverType, dnsResolver string, hosts []string, port int, isTLS bool, sniDomain string, lbPolicy LBPolicy, healthCheckPath string, healthCheckInterval int, healthyThreshold int, unhealthyThreshold int, expectedStatus int, failOpen bool, dnsServerName string, dnsBootstrapIPs []string, dnsCABundle string) (*Backend, error) {
+	if lbPolicy == "" {
+		lbPolicy = LBRoundRobin
+	}
+	if healthCheckInterval <= 0 {
+		healthCheckInterval = 10
+	}
+	if healthyThreshold <= 0 {
+		healthyThreshold = 2
+	}
+	if unhealthyThreshold <= 0 {
+		unhealthyThreshold = 3
+	}
+	if expectedStatus <= 0 {
+		expectedStatus = 200
+	}
+
 	// Check if backend exists
 	backend, err := findBackend(db, resolverType, dnsResolver, hosts, port, isTLS, sniDomain)
 	if err != nil {
@@ -130,23 +243,192 @@ func upsertBackend(db *gorm.DB, resolverType BackendResolverType, dnsResolver st
 	}
 
 	if backend != nil {
-		// Backend exists, with same config
-		return backend, nil
+		backend.LBPolicy = lbPolicy
+		backend.HealthCheckPath = healthCheckPath
+		backend.HealthCheckInterval = healthCheckInterval
+		backend.HealthyThreshold = healthyThreshold
+		backend.UnhealthyThreshold = unhealthyThreshold
+		backend.ExpectedStatus = expectedStatus
+		backend.FailOpen = failOpen
+		backend.DNSServerName = dnsServerName
+		backend.DNSBootstrapIPs = dnsBootstrapIPs
+		backend.DNSCABundle = dnsCABundle
+		return backend, db.Save(backend).Error
 	}
 
 	//	Create the entry
 	backend = &Backend{
-		ID:           uuid.NewString(),
-		ResolverType: resolverType,
-		DNSResolver:  dnsResolver,
-		Hosts:        hosts,
-		Port:         port,
-		IsTLS:        isTLS,
-		SNIDomain:    sniDomain,
+		ID:                  uuid.NewString(),
+		ResolverType:        resolverType,
+		DNSResolver:         dnsResolver,
+		Hosts:               hosts,
+		Port:                port,
+		IsTLS:               isTLS,
+		SNIDomain:           sniDomain,
+		LBPolicy:            lbPolicy,
+		HealthCheckPath:     healthCheckPath,
+		HealthCheckInterval: healthCheckInterval,
+		HealthyThreshold:    healthyThreshold,
+		UnhealthyThreshold:  unhealthyThreshold,
+		ExpectedStatus:      expectedStatus,
+		FailOpen:            failOpen,
+		DNSServerName:       dnsServerName,
+		DNSBootstrapIPs:     dnsBootstrapIPs,
+		DNSCABundle:         dnsCABundle,
 	}
 	return backend, db.Create(backend).Error
 }
 
+// listBackends returns every Backend row, for the admin API's read-only listing endpoints.
+func listBackends(db *gorm.DB) ([]Backend, error) {
+	var rows []Backend
+	err := db.Find(&rows).Error
+	return rows, err
+}
+
+func getBackendHealthID(backendID string, host string) string {
+	return fmt.Sprintf("%s|%s", backendID, host)
+}
+
+func getBackendHealth(db *gorm.DB, backendID string, host string) (*BackendHealth, error) {
+	var health BackendHealth
+	err := db.Where("id = ?", getBackendHealthID(backendID, host)).First(&health).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &health, nil
+}
+
+// listBackendHealth returns every known host's health state for a backend, used both by the
+// selection helpers and by BackendHealthServer's NATS response.
+func listBackendHealth(db *gorm.DB, backendID string) ([]BackendHealth, error) {
+	var rows []BackendHealth
+	err := db.Where("backend_id = ?", backendID).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// isBackendHealthCheckDue reports whether host on backend hasn't been probed yet, or was last
+// probed longer ago than backend.HealthCheckInterval.
+func isBackendHealthCheckDue(db *gorm.DB, backend Backend, host string) (bool, error) {
+	health, err := getBackendHealth(db, backend.ID, host)
+	if err != nil {
+		return false, err
+	}
+	if health == nil {
+		return true, nil
+	}
+	interval := backend.HealthCheckInterval
+	if interval <= 0 {
+		interval = 10
+	}
+	return time.Since(health.LastCheckedAt) >= time.Duration(interval)*time.Second, nil
+}
+
+// recordBackendHealthCheck applies the result of one probe to host's BackendHealth row,
+// transitioning Healthy only once HealthyThreshold/UnhealthyThreshold consecutive results are
+// seen, and reports whether that transition happened so callers know whether to broadcast.
+func recordBackendHealthCheck(db *gorm.DB, backend Backend, host string, checkErr error) (transitioned bool, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		health, err := getBackendHealth(tx, backend.ID, host)
+		if err != nil {
+			return err
+		}
+		if health == nil {
+			health = &BackendHealth{ID: getBackendHealthID(backend.ID, host), BackendID: backend.ID, Host: host, Healthy: true}
+		}
+		wasHealthy := health.Healthy
+
+		if checkErr == nil {
+			health.ConsecutiveSuccesses++
+			health.ConsecutiveFailures = 0
+			health.LastError = ""
+			if !health.Healthy {
+				threshold := backend.HealthyThreshold
+				if threshold <= 0 {
+					threshold = 2
+				}
+				if health.ConsecutiveSuccesses >= threshold {
+					health.Healthy = true
+				}
+			}
+		} else {
+			health.ConsecutiveFailures++
+			health.ConsecutiveSuccesses = 0
+			health.LastError = checkErr.Error()
+			if health.Healthy {
+				threshold := backend.UnhealthyThreshold
+				if threshold <= 0 {
+					threshold = 3
+				}
+				if health.ConsecutiveFailures >= threshold {
+					health.Healthy = false
+				}
+			}
+		}
+		health.LastCheckedAt = time.Now().UTC()
+
+		transitioned = health.Healthy != wasHealthy
+		return tx.Save(health).Error
+	})
+	return transitioned, err
+}
+
+func findMiddleware(db *gorm.DB, middlewareType MiddlewareType, config string) (*MiddlewareChain, error) {
+	var middleware MiddlewareChain
+	err := db.Where("type = ? AND config = ?", middlewareType, config).First(&middleware).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &middleware, nil
+}
+
+func upsertMiddleware(db *gorm.DB, middlewareType MiddlewareType, config string) (*MiddlewareChain, error) {
+	middleware, err := findMiddleware(db, middlewareType, config)
+	if err != nil {
+		return nil, err
+	}
+	if middleware != nil {
+		return middleware, nil
+	}
+
+	middleware = &MiddlewareChain{
+		ID:     uuid.NewString(),
+		Type:   middlewareType,
+		Config: config,
+	}
+	return middleware, db.Create(middleware).Error
+}
+
+// setIngressRuleMiddlewares replaces the ordered middleware chain attached to an ingress rule.
+// Like upsertIngressRule, this only touches join rows -- orphaned MiddlewareChain rows are
+// swept up later by cleanupUnusedBackendsAndListeners.
+func setIngressRuleMiddlewares(db *gorm.DB, ingressRuleID string, middlewareIDs []string) error {
+	if err := db.Where("ingress_rule_id = ?", ingressRuleID).Delete(&IngressRuleMiddleware{}).Error; err != nil {
+		return fmt.Errorf("failed to clear existing middleware chain: %w", err)
+	}
+
+	for order, middlewareID := range middlewareIDs {
+		link := &IngressRuleMiddleware{
+			IngressRuleID: ingressRuleID,
+			MiddlewareID:  middlewareID,
+			Order:         order,
+		}
+		if err := db.Create(link).Error; err != nil {
+			return fmt.Errorf("failed to attach middleware %s: %w", middlewareID, err)
+		}
+	}
+	return nil
+}
+
 func getIngressRuleID(protocol ProtocolType, listenerID string, domain string, routePrefix string) string {
 	if protocol == TCP {
 		return fmt.Sprintf("tcp:%s", listenerID)
@@ -168,7 +450,7 @@ func findIngressRule(db *gorm.DB, protocol ProtocolType, listenerID string, doma
 	return &rule, nil
 }
 
-func upsertIngressRule(db *gorm.DB, protocol ProtocolType, listenerID string, domain string, routePrefix string, backendID string, allowedCIDRs StringList, deniedCIDRs StringList, priority int) (*IngressRule, error) {
+func upsertIngressRule(db *gorm.DB, protocol ProtocolType, listenerID string, domain string, routePrefix string, allowedCIDRs StringList, deniedCIDRs StringList, priority int) (*IngressRule, error) {
 	//	Try to find existing ingress rule
 	ingressRule, err := findIngressRule(db, protocol, listenerID, domain, routePrefix)
 	if err != nil {
@@ -177,7 +459,6 @@ func upsertIngressRule(db *gorm.DB, protocol ProtocolType, listenerID string, do
 
 	// Update the existing record
 	if ingressRule != nil {
-		ingressRule.BackendID = backendID
 		ingressRule.AllowedCIDRs = allowedCIDRs
 		ingressRule.DeniedCIDRs = deniedCIDRs
 		ingressRule.Priority = priority
@@ -189,7 +470,6 @@ func upsertIngressRule(db *gorm.DB, protocol ProtocolType, listenerID string, do
 		ID:           getIngressRuleID(protocol, listenerID, domain, routePrefix),
 		Priority:     priority,
 		ListenerID:   listenerID,
-		BackendID:    backendID,
 		Domain:       domain,
 		RoutePrefix:  routePrefix,
 		AllowedCIDRs: allowedCIDRs,
@@ -201,11 +481,124 @@ func upsertIngressRule(db *gorm.DB, protocol ProtocolType, listenerID string, do
 	return ingressRule, db.Create(ingressRule).Error
 }
 
+// findHealthCheck looks up a HealthCheck by its full config, mirroring findBackend: every field
+// participates in identity, so two IngressRuleBackends with byte-identical pool health check
+// config share one HealthCheck row.
+func findHealthCheck(db *gorm.DB, path string, tcpOnly bool, intervalSeconds int, timeoutSeconds int, healthyThreshold int, unhealthyThreshold int, expectedStatusCodes StringList) (*HealthCheck, error) {
+	codesValue, err := expectedStatusCodes.Value()
+	if err != nil {
+		return nil, err
+	}
+
+	var check HealthCheck
+	err = db.Where("path = ? AND tcp_only = ? AND interval_seconds = ? AND timeout_seconds = ? AND healthy_threshold = ? AND unhealthy_threshold = ? AND expected_status_codes = ?",
+		path, tcpOnly, intervalSeconds, timeoutSeconds, healthyThreshold, unhealthyThreshold, codesValue).First(&check).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &check, nil
+}
+
+// upsertHealthCheck finds or creates the HealthCheck matching the given probe config, applying
+// the same zero-value defaults as the HealthCheck struct tags.
+func upsertHealthCheck(db *gorm.DB, path string, tcpOnly bool, intervalSeconds int, timeoutSeconds int, healthyThreshold int, unhealthyThreshold int, expectedStatusCodes []string) (*HealthCheck, error) {
+	if intervalSeconds <= 0 {
+		intervalSeconds = 10
+	}
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = 5
+	}
+	if healthyThreshold <= 0 {
+		healthyThreshold = 2
+	}
+	if unhealthyThreshold <= 0 {
+		unhealthyThreshold = 3
+	}
+
+	check, err := findHealthCheck(db, path, tcpOnly, intervalSeconds, timeoutSeconds, healthyThreshold, unhealthyThreshold, expectedStatusCodes)
+	if err != nil {
+		return nil, err
+	}
+	if check != nil {
+		return check, nil
+	}
+
+	check = &HealthCheck{
+		ID:                  uuid.NewString(),
+		Path:                path,
+		TCPOnly:             tcpOnly,
+		IntervalSeconds:     intervalSeconds,
+		TimeoutSeconds:      timeoutSeconds,
+		HealthyThreshold:    healthyThreshold,
+		UnhealthyThreshold:  unhealthyThreshold,
+		ExpectedStatusCodes: expectedStatusCodes,
+	}
+	return check, db.Create(check).Error
+}
+
+// ingressRuleBackendPlan is one resolved (Backend already upserted, HealthCheck already upserted)
+// pool entry, ready to be attached to an IngressRule by setIngressRuleBackends.
+type ingressRuleBackendPlan struct {
+	BackendID     string
+	Weight        int
+	IsBackup      bool
+	HealthCheckID string
+}
+
+// setIngressRuleBackends replaces the set of backend pools attached to an ingress rule. Like
+// setIngressRuleMiddlewares, this only touches join rows -- orphaned Backend/HealthCheck rows are
+// swept up later by cleanupUnusedBackendsAndListeners.
+func setIngressRuleBackends(db *gorm.DB, ingressRuleID string, plans []ingressRuleBackendPlan) error {
+	if err := db.Where("ingress_rule_id = ?", ingressRuleID).Delete(&IngressRuleBackend{}).Error; err != nil {
+		return fmt.Errorf("failed to clear existing backend pools: %w", err)
+	}
+
+	for _, plan := range plans {
+		weight := plan.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		link := &IngressRuleBackend{
+			ID:            uuid.NewString(),
+			IngressRuleID: ingressRuleID,
+			BackendID:     plan.BackendID,
+			Weight:        weight,
+			IsBackup:      plan.IsBackup,
+			HealthCheckID: plan.HealthCheckID,
+		}
+		if err := db.Create(link).Error; err != nil {
+			return fmt.Errorf("failed to attach backend pool %s: %w", plan.BackendID, err)
+		}
+	}
+	return nil
+}
+
+// listIngressRuleBackends returns every IngressRuleBackend row with its Backend and HealthCheck
+// preloaded, for the admin API's /v1/ingress_rule_backends listing.
+func listIngressRuleBackends(db *gorm.DB) ([]IngressRuleBackend, error) {
+	var rows []IngressRuleBackend
+	err := db.Preload("Backend").Preload("HealthCheck").Find(&rows).Error
+	return rows, err
+}
+
 func deleteIngressRule(db *gorm.DB, protocol ProtocolType, listenerID string, domain string, routePrefix string) error {
 	id := getIngressRuleID(protocol, listenerID, domain, routePrefix)
 	return db.Where("id = ?", id).Delete(&IngressRule{}).Error
 }
 
+// listIngressRules returns every IngressRule row, for the admin API's read-only listing
+// endpoints.
+func listIngressRules(db *gorm.DB) ([]IngressRule, error) {
+	var rows []IngressRule
+	err := db.Preload("Backends").Preload("Backends.Backend").Preload("Backends.HealthCheck").
+		Preload("Middlewares").Preload("Middlewares.Middleware").
+		Order("priority asc").Find(&rows).Error
+	return rows, err
+}
+
 func getHTTPRedirectRuleID(listenerID string, domain string, routePrefix string, isHTTPSRedirect bool) string {
 	redirectType := "https"
 	if !isHTTPSRedirect {
@@ -227,7 +620,7 @@ func findHTTPRedirectRule(db *gorm.DB, listenerID string, domain string, routePr
 	return &rule, nil
 }
 
-func upsertHTTPRedirectRule(db *gorm.DB, listenerID string, domain string, routePrefix string, isHttpsRedirect bool, schemeRedirect string, hostRedirect string, pathRedirect string, statusCode int, priority int) (*HTTPRedirectRule, error) {
+func upsertHTTPRedirectRule(db *gorm.DB, listenerID string, domain string, routePrefix string, isHttpsRedirect bool, schemeRedirect string, hostRedirect string, pathRedirect string, statusCode int, priority int, pathRegex string, pathReplacement string, preserveQuery bool, responseHeaders map[string]string) (*HTTPRedirectRule, error) {
 	// Try to find existing ingress rule
 	redirectRule, err := findHTTPRedirectRule(db, listenerID, domain, routePrefix, isHttpsRedirect)
 	if err != nil {
@@ -245,6 +638,10 @@ func upsertHTTPRedirectRule(db *gorm.DB, listenerID string, domain string, route
 		redirectRule.HostRedirect = hostRedirect
 		redirectRule.PathRedirect = pathRedirect
 		redirectRule.StatusCode = statusCode
+		redirectRule.PathRegex = pathRegex
+		redirectRule.PathReplacement = pathReplacement
+		redirectRule.PreserveQuery = preserveQuery
+		redirectRule.ResponseHeaders = responseHeaders
 		return redirectRule, db.Save(redirectRule).Error
 	}
 
@@ -260,6 +657,10 @@ func upsertHTTPRedirectRule(db *gorm.DB, listenerID string, domain string, route
 		HostRedirect:    hostRedirect,
 		PathRedirect:    pathRedirect,
 		StatusCode:      statusCode,
+		PathRegex:       pathRegex,
+		PathReplacement: pathReplacement,
+		PreserveQuery:   preserveQuery,
+		ResponseHeaders: responseHeaders,
 	}
 
 	return redirectRule, db.Create(redirectRule).Error
@@ -270,6 +671,81 @@ func deleteHTTPRedirectRule(db *gorm.DB, listenerID string, domain string, route
 	return db.Where("id = ?", id).Delete(&HTTPRedirectRule{}).Error
 }
 
+// listHTTPRedirectRules returns every HTTPRedirectRule row, for the admin API's read-only listing
+// endpoints.
+func listHTTPRedirectRules(db *gorm.DB) ([]HTTPRedirectRule, error) {
+	var rows []HTTPRedirectRule
+	err := db.Order("priority asc").Find(&rows).Error
+	return rows, err
+}
+
+func listTLSCertificates(db *gorm.DB) ([]TLSCertificate, error) {
+	var rows []TLSCertificate
+	err := db.Order("domain asc").Find(&rows).Error
+	return rows, err
+}
+
+// deleteTLSCertificatesNotIn, deleteIngressRulesNotIn and deleteHTTPRedirectRulesNotIn back the
+// full-state replace semantics of applyConfigSnapshot (see snapshot.go): anything not named in
+// ids no longer belongs in a snapshot-managed config, so it's removed rather than left to drift.
+//
+// deleteTLSCertificatesNotIn never touches Managed rows: those are owned by ACMERenewer (see
+// acme.go), not by whatever snapshot an operator last uploaded, so a snapshot that doesn't happen
+// to re-list a cert's current material must not delete it out from under the renewer.
+func deleteTLSCertificatesNotIn(db *gorm.DB, ids []string) error {
+	if len(ids) == 0 {
+		return db.Where("managed = ?", false).Delete(&TLSCertificate{}).Error
+	}
+	return db.Where("managed = ? AND id NOT IN (?)", false, ids).Delete(&TLSCertificate{}).Error
+}
+
+func deleteIngressRulesNotIn(db *gorm.DB, ids []string) error {
+	if len(ids) == 0 {
+		return db.Where("1 = 1").Delete(&IngressRule{}).Error
+	}
+	return db.Where("id NOT IN (?)", ids).Delete(&IngressRule{}).Error
+}
+
+func deleteHTTPRedirectRulesNotIn(db *gorm.DB, ids []string) error {
+	if len(ids) == 0 {
+		return db.Where("1 = 1").Delete(&HTTPRedirectRule{}).Error
+	}
+	return db.Where("id NOT IN (?)", ids).Delete(&HTTPRedirectRule{}).Error
+}
+
+// nextConfigRevision returns the next monotonic revision number for a ConfigRevision. Callers
+// are expected to hold the write transaction that will also insert the new row, so the gap
+// between this read and that insert can't race another apply.
+func nextConfigRevision(db *gorm.DB) (int64, error) {
+	var maxRevision int64
+	if err := db.Model(&ConfigRevision{}).Select("COALESCE(MAX(revision), 0)").Scan(&maxRevision).Error; err != nil {
+		return 0, err
+	}
+	return maxRevision + 1, nil
+}
+
+func getConfigRevision(db *gorm.DB, revision int64) (*ConfigRevision, error) {
+	var record ConfigRevision
+	err := db.Where("revision = ?", revision).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+func createConfigRevision(db *gorm.DB, revision int64, snapshotJSON string, sha256Hex string) (*ConfigRevision, error) {
+	record := &ConfigRevision{
+		Revision:     revision,
+		SnapshotJSON: snapshotJSON,
+		SHA256:       sha256Hex,
+		CreatedAt:    time.Now().UTC(),
+	}
+	return record, db.Create(record).Error
+}
+
 func cleanupUnusedBackendsAndListeners(db *gorm.DB) error {
 	//	Find listener_id from Ingress and Redirect Rule
 	var listenerIDsFromIngressRules []string
@@ -285,10 +761,10 @@ func cleanupUnusedBackendsAndListeners(db *gorm.DB) error {
 
 	allListenerIDs := slices.Concat(listenerIDsFromIngressRules, listenerIDsFromHTTPRedirectRules)
 
-	// Find backend_id from Ingress Rule
+	// Find backend_id from Ingress Rule Backend join rows
 	var allBackendIDs []string
-	if err := db.Model(&IngressRule{}).Pluck("backend_id", &allBackendIDs).Error; err != nil {
-		return fmt.Errorf("failed to get backend_id from Ingress Rule: %w", err)
+	if err := db.Model(&IngressRuleBackend{}).Pluck("backend_id", &allBackendIDs).Error; err != nil {
+		return fmt.Errorf("failed to get backend_id from Ingress Rule Backend: %w", err)
 	}
 
 	// Remove duplicates from both
@@ -308,5 +784,146 @@ func cleanupUnusedBackendsAndListeners(db *gorm.DB) error {
 			return fmt.Errorf("failed to delete unused listeners: %w", err)
 		}
 	}
+
+	// Remove BackendHealth rows left behind by backends that no longer exist.
+	if len(allBackendIDs) > 0 {
+		err := db.Where("backend_id NOT IN (?)", allBackendIDs).Delete(&BackendHealth{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete orphaned backend health rows: %w", err)
+		}
+	}
+
+	// Remove middleware rows no longer referenced by any IngressRuleMiddleware join row.
+	var referencedMiddlewareIDs []string
+	if err := db.Model(&IngressRuleMiddleware{}).Pluck("middleware_id", &referencedMiddlewareIDs).Error; err != nil {
+		return fmt.Errorf("failed to get middleware_id from IngressRuleMiddleware: %w", err)
+	}
+	referencedMiddlewareIDs = uniqueSortedStrings(referencedMiddlewareIDs)
+	if len(referencedMiddlewareIDs) > 0 {
+		err := db.Where("id NOT IN (?)", referencedMiddlewareIDs).Delete(&MiddlewareChain{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete unused middleware: %w", err)
+		}
+	}
+
+	// Remove HealthCheck rows no longer referenced by any IngressRuleBackend join row.
+	var referencedHealthCheckIDs []string
+	if err := db.Model(&IngressRuleBackend{}).Where("health_check_id != ?", "").Pluck("health_check_id", &referencedHealthCheckIDs).Error; err != nil {
+		return fmt.Errorf("failed to get health_check_id from IngressRuleBackend: %w", err)
+	}
+	referencedHealthCheckIDs = uniqueSortedStrings(referencedHealthCheckIDs)
+	if len(referencedHealthCheckIDs) > 0 {
+		err := db.Where("id NOT IN (?)", referencedHealthCheckIDs).Delete(&HealthCheck{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete unused health checks: %w", err)
+		}
+	}
 	return nil
 }
+
+func getACMEAccountByEmail(db *gorm.DB, email string) (*ACMEAccount, error) {
+	var account ACMEAccount
+	err := db.Where("email = ?", email).First(&account).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+func upsertACMEAccount(db *gorm.DB, email string, directoryURL string, keyPEM string, eabKeyID string, eabHMACKey string) (*ACMEAccount, error) {
+	account, err := getACMEAccountByEmail(db, email)
+	if err != nil {
+		return nil, err
+	}
+
+	if account != nil {
+		account.DirectoryURL = directoryURL
+		account.KeyPEM = keyPEM
+		account.EABKeyID = eabKeyID
+		account.EABHMACKey = eabHMACKey
+		return account, db.Save(account).Error
+	}
+
+	account = &ACMEAccount{
+		ID:           uuid.NewString(),
+		Email:        email,
+		DirectoryURL: directoryURL,
+		KeyPEM:       keyPEM,
+		EABKeyID:     eabKeyID,
+		EABHMACKey:   eabHMACKey,
+	}
+	return account, db.Create(account).Error
+}
+
+func getACMEChallengeForDomain(db *gorm.DB, domain string) (*ACMEChallenge, error) {
+	var challenge ACMEChallenge
+	err := db.Where("domain = ?", domain).First(&challenge).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+func upsertACMEChallenge(db *gorm.DB, domain string, challengeType string, dnsProvider string, dnsProviderConfig string) (*ACMEChallenge, error) {
+	challenge, err := getACMEChallengeForDomain(db, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	if challenge != nil {
+		challenge.ChallengeType = challengeType
+		challenge.DNSProvider = dnsProvider
+		challenge.DNSProviderConfig = dnsProviderConfig
+		return challenge, db.Save(challenge).Error
+	}
+
+	challenge = &ACMEChallenge{
+		ID:                uuid.NewString(),
+		Domain:            domain,
+		ChallengeType:     challengeType,
+		DNSProvider:       dnsProvider,
+		DNSProviderConfig: dnsProviderConfig,
+	}
+	return challenge, db.Create(challenge).Error
+}
+
+// domainsNeedingACMECert returns the set of domains referenced by IngressRule rows that have
+// IsTLS set but no matching TLSCertificate row yet (wildcard or exact), so the ACME renewer
+// knows what to issue on top of what it already renews.
+func domainsNeedingACMECert(db *gorm.DB) ([]string, error) {
+	var domains []string
+	err := db.Model(&IngressRule{}).
+		Joins("JOIN listeners ON listeners.id = ingress_rules.listener_id").
+		Where("listeners.is_tls = ? AND ingress_rules.domain != ?", true, "").
+		Distinct().
+		Pluck("ingress_rules.domain", &domains).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, domain := range domains {
+		exists, err := isTLSCertificateExist(db, getTLSCertificateID(domain, false))
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			if _, parent, ok := strings.Cut(domain, "."); ok {
+				exists, err = isTLSCertificateExist(db, getTLSCertificateID(parent, true))
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		if !exists {
+			missing = append(missing, domain)
+		}
+	}
+	return missing, nil
+}
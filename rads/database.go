@@ -62,7 +62,15 @@ func MigrateTables(db *gorm.DB) error {
 		&TLSCertificate{},
 		&Listener{},
 		&Backend{},
+		&BackendHealth{},
 		&IngressRule{},
 		&HTTPRedirectRule{},
+		&ACMEAccount{},
+		&ACMEChallenge{},
+		&MiddlewareChain{},
+		&IngressRuleMiddleware{},
+		&HealthCheck{},
+		&IngressRuleBackend{},
+		&ConfigRevision{},
 	)
 }
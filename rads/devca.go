@@ -0,0 +1,182 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// devCACertificateID is the distinguished TLSCertificate row that stores the dev-mode root CA
+// itself, as opposed to a leaf minted for a specific domain.
+const devCACertificateID = "__dev_ca__"
+
+// devCALeafLifetime controls how long minted leaves are valid for; short-lived since they're
+// re-minted on every agent restart anyway.
+const devCALeafLifetime = 90 * 24 * time.Hour
+
+// DevCA lazily mints leaf certificates for any ingress domain lacking a real TLSCertificate row,
+// signed by a persistent self-signed root so a full ingress topology can be brought up on a
+// laptop without wiring ACME. See Manager.EnsureDevCA / DevCA.LeafFor.
+type DevCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+
+	mu     sync.Mutex
+	serial int64
+	leaves map[string]*devLeaf
+}
+
+type devLeaf struct {
+	certPEM string
+	keyPEM  string
+}
+
+// EnsureDevCA loads the persisted dev CA from TLSCertificate (id __dev_ca__), or generates and
+// persists a new one if absent. The PKIX CommonName is derived from AgentID so certs minted by
+// different agents are distinguishable in a browser's trust store.
+func (m *Manager) EnsureDevCA() (*DevCA, error) {
+	exists, err := isTLSCertificateExist(m.ReadOnlyDB, devCACertificateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for existing dev CA: %w", err)
+	}
+
+	if exists {
+		existing, err := getTLSCertByID(m.ReadOnlyDB, devCACertificateID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load persisted dev CA: %w", err)
+		}
+		cert, key, err := decodeDevCA(existing.Cert, existing.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load persisted dev CA: %w", err)
+		}
+		return &DevCA{cert: cert, key: key, leaves: map[string]*devLeaf{}}, nil
+	}
+
+	cert, key, certPEM, keyPEM, err := generateDevCA(m.Config.AgentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate dev CA: %w", err)
+	}
+
+	if _, err := upsertTLSCertificate(m.ReadWriteDB, devCACertificateID, false, certPEM, keyPEM, cert.NotAfter, false); err != nil {
+		return nil, fmt.Errorf("failed to persist dev CA: %w", err)
+	}
+
+	return &DevCA{cert: cert, key: key, leaves: map[string]*devLeaf{}}, nil
+}
+
+func generateDevCA(agentID string) (*x509.Certificate, *ecdsa.PrivateKey, string, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: fmt.Sprintf("vessel dev CA (%s)", agentID)},
+		NotBefore:             time.Now().Add(-1 * time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+	keyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+
+	return cert, key, certPEM, keyPEM, nil
+}
+
+func decodeDevCA(certPEM, keyPEM string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		return nil, nil, fmt.Errorf("invalid dev CA certificate PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyBlock, _ := pem.Decode([]byte(keyPEM))
+	if keyBlock == nil {
+		return nil, nil, fmt.Errorf("invalid dev CA key PEM")
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cert, key, nil
+}
+
+// LeafFor returns a PEM cert/key pair for sni, minting and caching one on first use. Minted
+// material round-trips ValidateCertAndKey like any manually-uploaded certificate.
+func (ca *DevCA) LeafFor(sni string) (certPEM string, keyPEM string, err error) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+
+	if leaf, ok := ca.leaves[sni]; ok {
+		return leaf.certPEM, leaf.keyPEM, nil
+	}
+
+	ca.serial++
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(ca.serial),
+		Subject:      pkix.Name{CommonName: sni},
+		DNSNames:     []string{sni},
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(devCALeafLifetime),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return "", "", err
+	}
+
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+
+	if err := ValidateCertAndKey(certPEM, keyPEM); err != nil {
+		return "", "", fmt.Errorf("minted leaf for %s failed self-validation: %w", sni, err)
+	}
+
+	ca.leaves[sni] = &devLeaf{certPEM: certPEM, keyPEM: keyPEM}
+	return certPEM, keyPEM, nil
+}
+
+// CACertPEM returns the root CA certificate so it can be exposed over the NATS control plane
+// for operators to trust in browsers.
+func (ca *DevCA) CACertPEM() string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}))
+}
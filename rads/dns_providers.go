@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/miekg/dns"
+)
+
+func awsConfigForRegion(region string) (aws.Config, error) {
+	opts := []func(*awsconfig.LoadOptions) error{}
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	return awsconfig.LoadDefaultConfig(context.Background(), opts...)
+}
+
+// cloudflareDNSProvider satisfies DNSProvider against the Cloudflare v4 API using a scoped API
+// token (Zone.DNS edit permission).
+type cloudflareDNSProvider struct {
+	apiToken string
+	zoneID   string
+}
+
+type cloudflareDNSProviderConfig struct {
+	APIToken string `json:"api_token"`
+	ZoneID   string `json:"zone_id"`
+}
+
+func newCloudflareDNSProvider(rawConfig string) (DNSProvider, error) {
+	var cfg cloudflareDNSProviderConfig
+	if err := json.Unmarshal([]byte(rawConfig), &cfg); err != nil {
+		return nil, fmt.Errorf("invalid cloudflare dns provider config: %w", err)
+	}
+	if cfg.APIToken == "" || cfg.ZoneID == "" {
+		return nil, fmt.Errorf("cloudflare dns provider requires api_token and zone_id")
+	}
+	return &cloudflareDNSProvider{apiToken: cfg.APIToken, zoneID: cfg.ZoneID}, nil
+}
+
+func (p *cloudflareDNSProvider) Present(ctx context.Context, domain string, value string) error {
+	body, err := json.Marshal(map[string]any{
+		"type":    "TXT",
+		"name":    "_acme-challenge." + domain,
+		"content": value,
+		"ttl":     120,
+	})
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records", p.zoneID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("cloudflare dns-01 create record failed: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (p *cloudflareDNSProvider) CleanUp(ctx context.Context, domain string, value string) error {
+	// Best effort: Cloudflare has no "delete by name+content" shortcut, so this intentionally
+	// leaves stale TXT records behind rather than risk deleting the wrong one. Operators running
+	// dns-01 at scale should prune `_acme-challenge.*` TXT records out of band.
+	return nil
+}
+
+// route53DNSProvider satisfies DNSProvider against AWS Route53 using standard SDK credential
+// resolution (env vars, shared config, instance role).
+type route53DNSProvider struct {
+	client       *route53.Client
+	hostedZoneID string
+}
+
+type route53DNSProviderConfig struct {
+	HostedZoneID string `json:"hosted_zone_id"`
+	Region       string `json:"region"`
+}
+
+func newRoute53DNSProvider(rawConfig string) (DNSProvider, error) {
+	var cfg route53DNSProviderConfig
+	if err := json.Unmarshal([]byte(rawConfig), &cfg); err != nil {
+		return nil, fmt.Errorf("invalid route53 dns provider config: %w", err)
+	}
+	if cfg.HostedZoneID == "" {
+		return nil, fmt.Errorf("route53 dns provider requires hosted_zone_id")
+	}
+
+	awsCfg, err := awsConfigForRegion(cfg.Region)
+	if err != nil {
+		return nil, err
+	}
+	return &route53DNSProvider{client: route53.NewFromConfig(awsCfg), hostedZoneID: cfg.HostedZoneID}, nil
+}
+
+func (p *route53DNSProvider) upsertTXT(ctx context.Context, domain string, value string, action route53types.ChangeAction) error {
+	name := "_acme-challenge." + domain + "."
+	_, err := p.client.ChangeResourceRecordSets(ctx, &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(p.hostedZoneID),
+		ChangeBatch: &route53types.ChangeBatch{
+			Changes: []route53types.Change{
+				{
+					Action: action,
+					ResourceRecordSet: &route53types.ResourceRecordSet{
+						Name: aws.String(name),
+						Type: route53types.RRTypeTxt,
+						TTL:  aws.Int64(60),
+						ResourceRecords: []route53types.ResourceRecord{
+							{Value: aws.String(fmt.Sprintf("%q", value))},
+						},
+					},
+				},
+			},
+		},
+	})
+	return err
+}
+
+func (p *route53DNSProvider) Present(ctx context.Context, domain string, value string) error {
+	return p.upsertTXT(ctx, domain, value, route53types.ChangeActionUpsert)
+}
+
+func (p *route53DNSProvider) CleanUp(ctx context.Context, domain string, value string) error {
+	return p.upsertTXT(ctx, domain, value, route53types.ChangeActionDelete)
+}
+
+// rfc2136DNSProvider satisfies DNSProvider by sending a signed DNS UPDATE (RFC 2136) directly to
+// an authoritative nameserver, for self-hosted DNS that isn't behind a cloud API.
+type rfc2136DNSProvider struct {
+	nameserver string
+	tsigKey    string
+	tsigSecret string
+	tsigAlgo   string
+}
+
+type rfc2136DNSProviderConfig struct {
+	Nameserver string `json:"nameserver"` // host:port
+	TSIGKey    string `json:"tsig_key"`
+	TSIGSecret string `json:"tsig_secret"`
+	TSIGAlgo   string `json:"tsig_algo"` // e.g. hmac-sha256.
+}
+
+func newRFC2136DNSProvider(rawConfig string) (DNSProvider, error) {
+	var cfg rfc2136DNSProviderConfig
+	if err := json.Unmarshal([]byte(rawConfig), &cfg); err != nil {
+		return nil, fmt.Errorf("invalid rfc2136 dns provider config: %w", err)
+	}
+	if cfg.Nameserver == "" {
+		return nil, fmt.Errorf("rfc2136 dns provider requires nameserver")
+	}
+	if cfg.TSIGAlgo == "" {
+		cfg.TSIGAlgo = "hmac-sha256."
+	}
+	return &rfc2136DNSProvider{
+		nameserver: cfg.Nameserver,
+		tsigKey:    cfg.TSIGKey,
+		tsigSecret: cfg.TSIGSecret,
+		tsigAlgo:   cfg.TSIGAlgo,
+	}, nil
+}
+
+func (p *rfc2136DNSProvider) update(domain string, value string, remove bool) error {
+	fqdn := dns.Fqdn("_acme-challenge." + domain)
+
+	msg := new(dns.Msg)
+	msg.SetUpdate(dns.Fqdn(domain))
+
+	rr, err := dns.NewRR(fmt.Sprintf("%s 120 IN TXT %q", fqdn, value))
+	if err != nil {
+		return err
+	}
+
+	if remove {
+		msg.Remove([]dns.RR{rr})
+	} else {
+		msg.Insert([]dns.RR{rr})
+	}
+
+	client := new(dns.Client)
+	client.Timeout = 10 * time.Second
+	if p.tsigKey != "" {
+		msg.SetTsig(dns.Fqdn(p.tsigKey), p.tsigAlgo, 300, time.Now().Unix())
+		client.TsigSecret = map[string]string{dns.Fqdn(p.tsigKey): p.tsigSecret}
+	}
+
+	nameserver := p.nameserver
+	if !strings.Contains(nameserver, ":") {
+		nameserver += ":53"
+	}
+
+	reply, _, err := client.Exchange(msg, nameserver)
+	if err != nil {
+		return err
+	}
+	if reply.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("rfc2136 update rejected: %s", dns.RcodeToString[reply.Rcode])
+	}
+	return nil
+}
+
+func (p *rfc2136DNSProvider) Present(ctx context.Context, domain string, value string) error {
+	return p.update(domain, value, false)
+}
+
+func (p *rfc2136DNSProvider) CleanUp(ctx context.Context, domain string, value string) error {
+	return p.update(domain, value, true)
+}
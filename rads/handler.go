@@ -11,12 +11,49 @@ import (
 )
 
 var EventToRequestTypeMapping = map[string]reflect.Type{
-	"v1.tls_certificate.upsert":    reflect.TypeOf(TLSCertificateUpsertV1{}),
-	"v1.tls_certificate.delete":    reflect.TypeOf(TLSCertificateDeleteV1{}),
-	"v1.ingress_rule.upsert":       reflect.TypeOf(IngressRuleUpsertV1{}),
-	"v1.ingress_rule.delete":       reflect.TypeOf(IngressRuleDeleteV1{}),
-	"v1.http_redirect_rule.upsert": reflect.TypeOf(HTTPRedirectRuleUpsertV1{}),
-	"v1.http_redirect_rule.delete": reflect.TypeOf(HTTPRedirectRuleDeleteV1{}),
+	"v1.tls_certificate.upsert":       reflect.TypeOf(TLSCertificateUpsertV1{}),
+	"v1.tls_certificate.delete":       reflect.TypeOf(TLSCertificateDeleteV1{}),
+	"v1.tls_certificate.acme_config":  reflect.TypeOf(TLSCertificateACMEConfigV1{}),
+	"v1.tls_certificate.acme_request": reflect.TypeOf(TLSCertificateACMERequestV1{}),
+	"v1.ingress_rule.upsert":          reflect.TypeOf(IngressRuleUpsertV1{}),
+	"v1.ingress_rule.delete":          reflect.TypeOf(IngressRuleDeleteV1{}),
+	"v1.http_redirect_rule.upsert":    reflect.TypeOf(HTTPRedirectRuleUpsertV1{}),
+	"v1.http_redirect_rule.delete":    reflect.TypeOf(HTTPRedirectRuleDeleteV1{}),
+}
+
+// eventAffectedResourceTypes returns the ADS resource type URLs (see ads.go) that processing the
+// given event can change, so BroadcastChangesToProxies only nudges the ADS streams that actually
+// need to resend something. ingress_rule/http_redirect_rule events ride along with
+// Listeners/Backends too, since upsertListener/upsertBackend/cleanupUnusedBackendsAndListeners
+// mean processing one can create or prune rows of those types as a side effect.
+func eventAffectedResourceTypes(event string) []string {
+	switch {
+	case strings.HasPrefix(event, "v1.tls_certificate."):
+		return []string{TypeURLTLSCertificates}
+	case strings.HasPrefix(event, "v1.ingress_rule."):
+		return []string{TypeURLIngressRules, TypeURLListeners, TypeURLBackends}
+	case strings.HasPrefix(event, "v1.http_redirect_rule."):
+		return []string{TypeURLHTTPRedirectRules, TypeURLListeners}
+	default:
+		return nil
+	}
+}
+
+// affectedResourceTypes unions eventAffectedResourceTypes across a batch of messages, for
+// ProcessRequests (manager.go) to broadcast only the resource types actually touched by that
+// batch instead of every type unconditionally.
+func affectedResourceTypes(messages []Message) []string {
+	seen := make(map[string]bool)
+	var typeURLs []string
+	for i := range messages {
+		for _, typeURL := range eventAffectedResourceTypes(messages[i].Event) {
+			if !seen[typeURL] {
+				seen[typeURL] = true
+				typeURLs = append(typeURLs, typeURL)
+			}
+		}
+	}
+	return typeURLs
 }
 
 func parseEvent(event string, data []byte) (isParsed bool, requestID string, requestedAt *time.Time, message MessageInterface, err error) {
@@ -60,7 +97,7 @@ func parseEvent(event string, data []byte) (isParsed bool, requestID string, req
 	return
 }
 
-func processMessage(db *gorm.DB, msg *Message) {
+func processMessage(db *gorm.DB, msg *Message, metrics *PipelineMetrics) {
 	// Set current time
 	currentTime := time.Now().UTC()
 	msg.ProcessedAt = &currentTime
@@ -74,6 +111,7 @@ func processMessage(db *gorm.DB, msg *Message) {
 		if err != nil {
 			fmt.Printf("failed to save message: %v\n", err)
 		}
+		metrics.recordProcessed(msg)
 	}()
 
 	_, ok := EventToRequestTypeMapping[msg.Event]
@@ -101,6 +139,24 @@ func processMessage(db *gorm.DB, msg *Message) {
 	}
 }
 
+// messageToResponsePayload builds the ResponsePayloadV1 a completed Message produces, shared by
+// the NATS reply path (SendResponsesToQueue) and the admin API's synchronous HTTP responses (see
+// adminapi.go) so both transports report the exact same outcome shape for the exact same event.
+func messageToResponsePayload(msg *Message) ResponsePayloadV1 {
+	payload := ResponsePayloadV1{
+		Event:        msg.Event,
+		MessageID:    msg.ID,
+		Success:      msg.Success,
+		Data:         json.RawMessage(msg.ResponsePayload),
+		ErrorMessage: msg.ErrorMessage,
+		ProcessedAt:  *msg.ProcessedAt,
+		QueuedAt:     *msg.QueuedAt,
+	}
+	payload.RequestID = msg.RequestID
+	payload.RequestedAt = *msg.RequestedAt
+	return payload
+}
+
 // NOTE: In case of error as well, don't expect that transaction will be rolled back.
 // It's up to the `Process` function to handle the error and rollback the changes.
 // The raised error will be propagated to the client.
@@ -124,15 +180,8 @@ func (r *TLSCertificateUpsertV1) Process(db *gorm.DB) (json.RawMessage, error) {
 		r.Key += "\n"
 	}
 
-	// Find the existing record first by domain and is_wildcard
-	id := getTLSCertificateID(r.Domain, r.IsWildcard)
-	isExist, err := isTLSCertificateExist(db, id)
-	if err != nil {
-		return nil, err
-	}
-
 	// Validate TLS certificate
-	if err = ValidateCertAndKey(r.Cert, r.Key); err != nil {
+	if err := ValidateCertAndKey(r.Cert, r.Key); err != nil {
 		return nil, err
 	}
 
@@ -142,20 +191,11 @@ func (r *TLSCertificateUpsertV1) Process(db *gorm.DB) (json.RawMessage, error) {
 		return nil, err
 	}
 
-	// Update in DB
-	certificateRecord := TLSCertificate{
-		ID:         id,
-		Domain:     r.Domain,
-		IsWildcard: r.IsWildcard,
-		Cert:       r.Cert,
-		Key:        r.Key,
-		ExpiresAt:  certExpiry,
-	}
-
-	if isExist {
-		err = db.Save(&certificateRecord).Error
-	} else {
-		err = db.Create(&certificateRecord).Error
+	// A manual upload always takes the domain out of ACME management -- otherwise the renewer
+	// would silently clobber it the next time it came up for renewal.
+	certificateRecord, err := upsertTLSCertificate(db, r.Domain, r.IsWildcard, r.Cert, r.Key, certExpiry, false)
+	if err != nil {
+		return nil, err
 	}
 
 	// Marshal the record to JSON
@@ -180,6 +220,57 @@ func (r *TLSCertificateDeleteV1) Process(db *gorm.DB) (json.RawMessage, error) {
 	return nil, err
 }
 
+func (r *TLSCertificateACMEConfigV1) Process(db *gorm.DB) (json.RawMessage, error) {
+	if r.Domain == "" {
+		return nil, errors.New("domain is required")
+	}
+
+	challengeType := r.ChallengeType
+	if challengeType == "" {
+		challengeType = "http-01"
+	}
+	switch challengeType {
+	case "http-01":
+		if r.DNSProvider != "" {
+			return nil, errors.New("dns_provider is only valid with challenge_type dns-01")
+		}
+	case "dns-01":
+		if r.DNSProvider == "" {
+			return nil, errors.New("dns_provider is required for challenge_type dns-01")
+		}
+		// Validate the provider name and config shape up front, rather than only discovering a
+		// typo the next time the renewer tries (and fails) to issue for this domain.
+		if _, err := dnsProviderFor(r.DNSProvider, r.DNSProviderConfig); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported challenge_type: %s", r.ChallengeType)
+	}
+
+	challenge, err := upsertACMEChallenge(db, r.Domain, challengeType, r.DNSProvider, r.DNSProviderConfig)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(challenge)
+}
+
+func (r *TLSCertificateACMERequestV1) Process(db *gorm.DB) (json.RawMessage, error) {
+	if r.Domain == "" {
+		return nil, errors.New("domain is required")
+	}
+
+	// An empty cert and zero ExpiresAt satisfy getManagedCertsDueForRenewal's due-for-renewal
+	// condition, so the existing renewer loop issues this certificate on its next tick without
+	// needing any request-specific scheduling of its own. This holds for non-wildcard domains only:
+	// renewDueCertificates skips IsWildcard rows, so a wildcard request is stored but no
+	// certificate is issued for it.
+	certificateRecord, err := upsertTLSCertificate(db, r.Domain, r.IsWildcard, "", "", time.Time{}, true)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(certificateRecord)
+}
+
 func (r *IngressRuleUpsertV1) Process(db *gorm.DB) (json.RawMessage, error) {
 	// Payload Validation
 	if r.BindIP != "0.0.0.0" {
@@ -207,14 +298,33 @@ func (r *IngressRuleUpsertV1) Process(db *gorm.DB) (json.RawMessage, error) {
 		}
 	}
 
-	// Backend validation
-	if len(r.BackendHosts) == 0 {
+	// Backend pool validation. Backends, when set, replaces the single implicit pool described
+	// by the flat Backend* fields above -- see backendSpecs.
+	backends := r.backendSpecs()
+	if len(backends) == 0 {
 		return nil, errors.New("at least one backend host is required")
 	}
+	for i, spec := range backends {
+		if len(spec.BackendHosts) == 0 {
+			return nil, fmt.Errorf("backends[%d]: at least one backend host is required", i)
+		}
+
+		switch spec.BackendResolver {
+		case DNS_RESOLVER, DOT_RESOLVER, DOH_RESOLVER:
+			if spec.BackendDNSResolver == "" {
+				return nil, fmt.Errorf("backends[%d]: backend_dns_resolver is required for %s resolver", i, spec.BackendResolver)
+			}
+		}
 
-	// Resolver Validation
-	if r.BackendResolver == DNS_RESOLVER && r.BackendDNSResolver == "" {
-		return nil, errors.New("backend_dns_resolver is required for DNS resolver")
+		switch spec.BackendLBPolicy {
+		case "", LBRoundRobin, LBLeastConn, LBRandom, LBIPHash:
+		default:
+			return nil, fmt.Errorf("backends[%d]: invalid backend_lb_policy: %s", i, spec.BackendLBPolicy)
+		}
+
+		if spec.BackendExpectedStatus != 0 && (spec.BackendExpectedStatus < 100 || spec.BackendExpectedStatus > 599) {
+			return nil, fmt.Errorf("backends[%d]: backend_expected_status must be a valid HTTP status code", i)
+		}
 	}
 
 	// CIDR validation
@@ -230,24 +340,76 @@ func (r *IngressRuleUpsertV1) Process(db *gorm.DB) (json.RawMessage, error) {
 		}
 	}
 
+	// Middleware validation
+	for _, middleware := range r.Middlewares {
+		if err := validateMiddlewareConfig(middleware.Type, middleware.Config); err != nil {
+			return nil, fmt.Errorf("invalid middleware %s: %w", middleware.Type, err)
+		}
+	}
+
 	// Create / Update the listener
 	listener, err := upsertListener(db, r.BindIP, r.Port, r.Protocol, r.IsTLS)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upsert listener: %w", err)
 	}
 
-	// Create / Update the backend
-	backend, err := upsertBackend(db, r.BackendResolver, r.BackendDNSResolver, r.BackendHosts, r.BackendPort, r.IsTLS, r.BackendSNIDomain)
-	if err != nil {
-		return nil, fmt.Errorf("failed to upsert backend: %w", err)
+	// Create / Update each backend pool, and its pool health check if one was given.
+	plans := make([]ingressRuleBackendPlan, 0, len(backends))
+	for i, spec := range backends {
+		backend, err := upsertBackend(db, spec.BackendResolver, spec.BackendDNSResolver, spec.BackendHosts, spec.BackendPort, spec.BackendIsTLS, spec.BackendSNIDomain,
+			spec.BackendLBPolicy, spec.BackendHealthCheckPath, spec.BackendHealthCheckIntervalSeconds, spec.BackendHealthyThreshold, spec.BackendUnhealthyThreshold, spec.BackendExpectedStatus, spec.BackendHealthCheckFailOpen,
+			spec.BackendDNSServerName, spec.BackendDNSBootstrapIPs, spec.BackendDNSCABundle)
+		if err != nil {
+			return nil, fmt.Errorf("backends[%d]: failed to upsert backend: %w", i, err)
+		}
+
+		plan := ingressRuleBackendPlan{BackendID: backend.ID, Weight: spec.Weight, IsBackup: spec.IsBackup}
+		if spec.PoolHealthCheck != nil {
+			check, err := upsertHealthCheck(db, spec.PoolHealthCheck.Path, spec.PoolHealthCheck.TCPOnly, spec.PoolHealthCheck.IntervalSeconds, spec.PoolHealthCheck.TimeoutSeconds,
+				spec.PoolHealthCheck.HealthyThreshold, spec.PoolHealthCheck.UnhealthyThreshold, spec.PoolHealthCheck.ExpectedStatusCodes)
+			if err != nil {
+				return nil, fmt.Errorf("backends[%d]: failed to upsert pool health check: %w", i, err)
+			}
+			plan.HealthCheckID = check.ID
+		}
+		plans = append(plans, plan)
 	}
 
 	// Create / Update the ingress rule
-	ingressRule, err := upsertIngressRule(db, r.Protocol, listener.ID, r.Domain, r.RoutePrefix, backend.ID, r.AllowedCIDRs, r.DeniedCIDRs, r.Priority)
+	ingressRule, err := upsertIngressRule(db, r.Protocol, listener.ID, r.Domain, r.RoutePrefix, r.AllowedCIDRs, r.DeniedCIDRs, r.Priority)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upsert ingress rule: %w", err)
 	}
 
+	if err := setIngressRuleBackends(db, ingressRule.ID, plans); err != nil {
+		return nil, fmt.Errorf("failed to set backend pools: %w", err)
+	}
+
+	// Create / Update the middleware chain
+	middlewareIDs := make([]string, 0, len(r.Middlewares))
+	for _, middleware := range r.Middlewares {
+		configBytes, err := json.Marshal(middleware.Config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal middleware config: %w", err)
+		}
+		chain, err := upsertMiddleware(db, middleware.Type, string(configBytes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to upsert middleware: %w", err)
+		}
+		middlewareIDs = append(middlewareIDs, chain.ID)
+	}
+	if err := setIngressRuleMiddlewares(db, ingressRule.ID, middlewareIDs); err != nil {
+		return nil, fmt.Errorf("failed to set middleware chain: %w", err)
+	}
+
+	// Re-fetch with the backend pools and middleware chain preloaded so the reply reflects what
+	// was just attached.
+	if err := db.Preload("Backends").Preload("Backends.Backend").Preload("Backends.HealthCheck").
+		Preload("Middlewares").Preload("Middlewares.Middleware").
+		First(ingressRule, "id = ?", ingressRule.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to reload ingress rule: %w", err)
+	}
+
 	// Marshal the record to JSON
 	jsonStr, err := json.Marshal(ingressRule)
 	if err != nil {
@@ -256,6 +418,37 @@ func (r *IngressRuleUpsertV1) Process(db *gorm.DB) (json.RawMessage, error) {
 	return jsonStr, nil
 }
 
+// backendSpecs returns r.Backends verbatim when set, or else a single-entry slice built from the
+// flat Backend* fields -- so callers that haven't adopted multi-backend fan-out keep working
+// unchanged.
+func (r *IngressRuleUpsertV1) backendSpecs() []BackendSpecV1 {
+	if len(r.Backends) > 0 {
+		return r.Backends
+	}
+	if len(r.BackendHosts) == 0 {
+		return nil
+	}
+	return []BackendSpecV1{{
+		BackendResolver:                   r.BackendResolver,
+		BackendDNSResolver:                r.BackendDNSResolver,
+		BackendHosts:                      r.BackendHosts,
+		BackendPort:                       r.BackendPort,
+		BackendDNSServerName:              r.BackendDNSServerName,
+		BackendDNSBootstrapIPs:            r.BackendDNSBootstrapIPs,
+		BackendDNSCABundle:                r.BackendDNSCABundle,
+		BackendIsTLS:                      r.IsTLS,
+		BackendSNIDomain:                  r.BackendSNIDomain,
+		BackendLBPolicy:                   r.BackendLBPolicy,
+		BackendHealthCheckPath:            r.BackendHealthCheckPath,
+		BackendHealthCheckIntervalSeconds: r.BackendHealthCheckIntervalSeconds,
+		BackendHealthyThreshold:           r.BackendHealthyThreshold,
+		BackendUnhealthyThreshold:         r.BackendUnhealthyThreshold,
+		BackendExpectedStatus:             r.BackendExpectedStatus,
+		BackendHealthCheckFailOpen:        r.BackendHealthCheckFailOpen,
+		Weight:                            1,
+	}}
+}
+
 func (r *IngressRuleDeleteV1) Process(db *gorm.DB) (json.RawMessage, error) {
 	// Payload Validation
 	if r.BindIP != "0.0.0.0" {
@@ -302,6 +495,31 @@ func (r *HTTPRedirectRuleUpsertV1) Process(db *gorm.DB) (json.RawMessage, error)
 		r.RoutePrefix = "/"
 	}
 
+	// IsHttpsRedirect only makes sense going from a plaintext listener to an https target: the
+	// listener itself must be non-TLS (mirrors the TLS conflict check in upsertListener), and the
+	// target scheme must actually be https.
+	if r.IsHttpsRedirect {
+		if r.IsTLS {
+			return nil, errors.New("is_https_redirect cannot be combined with is_tls: the redirect must live on the plaintext listener it redirects away from")
+		}
+		if r.SchemeRedirect == "" {
+			r.SchemeRedirect = "https"
+		} else if r.SchemeRedirect != "https" {
+			return nil, fmt.Errorf("is_https_redirect requires scheme_redirect to be https, got %q", r.SchemeRedirect)
+		}
+	}
+
+	// Validate the optional regex path rewrite
+	if r.PathRegex != "" {
+		re, err := compileCachedPathRegex(r.PathRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path_regex: %w", err)
+		}
+		if err := validatePathReplacement(re, r.PathReplacement); err != nil {
+			return nil, fmt.Errorf("invalid path_replacement: %w", err)
+		}
+	}
+
 	// Create / Update the listener
 	listener, err := upsertListener(db, r.BindIP, r.Port, HTTP, r.IsTLS)
 	if err != nil {
@@ -309,7 +527,7 @@ func (r *HTTPRedirectRuleUpsertV1) Process(db *gorm.DB) (json.RawMessage, error)
 	}
 
 	// Create / Update the redirect rule
-	redirectRule, err := upsertHTTPRedirectRule(db, listener.ID, r.Domain, r.RoutePrefix, r.IsHttpsRedirect, r.SchemeRedirect, r.HostRedirect, r.PathRedirect, r.StatusCode, r.Priority)
+	redirectRule, err := upsertHTTPRedirectRule(db, listener.ID, r.Domain, r.RoutePrefix, r.IsHttpsRedirect, r.SchemeRedirect, r.HostRedirect, r.PathRedirect, r.StatusCode, r.Priority, r.PathRegex, r.PathReplacement, r.PreserveQuery, r.ResponseHeaders)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upsert redirect rule: %w", err)
 	}
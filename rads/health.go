@@ -0,0 +1,173 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// healthCheckTickInterval is how often the supervisor wakes up to see which hosts are due
+	// for a probe. It's intentionally shorter than any backend's HealthCheckInterval so that
+	// interval is honored fairly closely without needing a per-backend timer.
+	healthCheckTickInterval = 2 * time.Second
+	healthCheckTimeout      = 5 * time.Second
+)
+
+// HealthChecker runs a supervisor that probes every Backend host on its own configured cadence
+// (Backend.HealthCheckInterval) and records the outcome via recordBackendHealthCheck, flipping a
+// host up/down once it crosses HealthyThreshold/UnhealthyThreshold consecutive results.
+func (m *Manager) HealthChecker() {
+	m.Wg.Add(1)
+	defer m.Wg.Done()
+
+	ticker := time.NewTicker(healthCheckTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.Context.Done():
+			return
+		case <-ticker.C:
+			m.runDueHealthChecks()
+		}
+	}
+}
+
+func (m *Manager) runDueHealthChecks() {
+	var backends []Backend
+	if err := m.ReadOnlyDB.Find(&backends).Error; err != nil {
+		fmt.Printf("HealthCheck: failed to list backends: %v\n", err)
+		return
+	}
+
+	for _, backend := range backends {
+		for _, host := range backend.Hosts {
+			due, err := isBackendHealthCheckDue(m.ReadOnlyDB, backend, host)
+			if err != nil {
+				fmt.Printf("HealthCheck: failed to check due state for %s/%s: %v\n", backend.ID, host, err)
+				continue
+			}
+			if !due {
+				continue
+			}
+
+			m.Metrics.incBackendInFlight(backend.ID)
+			checkErr := probeBackendHost(&backend, host)
+			m.Metrics.decBackendInFlight(backend.ID)
+			transitioned, err := recordBackendHealthCheck(m.ReadWriteDB, backend, host, checkErr)
+			if err != nil {
+				fmt.Printf("HealthCheck: failed to record result for %s/%s: %v\n", backend.ID, host, err)
+				continue
+			}
+			if transitioned {
+				m.BroadcastChangesToProxies(TypeURLBackends)
+			}
+		}
+	}
+}
+
+// probeBackendHost checks a single host of backend. With HealthCheckPath unset it's a plain TCP
+// connect; with it set, a TCP connect is followed by an HTTP GET for that path (HTTPS with
+// SNIDomain when backend.IsTLS), requiring ExpectedStatus back.
+func probeBackendHost(backend *Backend, host string) error {
+	addr := net.JoinHostPort(host, fmt.Sprintf("%d", backend.Port))
+
+	conn, err := net.DialTimeout("tcp", addr, healthCheckTimeout)
+	if err != nil {
+		return fmt.Errorf("tcp connect failed: %w", err)
+	}
+	_ = conn.Close()
+
+	if backend.HealthCheckPath == "" {
+		return nil
+	}
+
+	scheme := "http"
+	client := &http.Client{Timeout: healthCheckTimeout}
+	if backend.IsTLS {
+		scheme = "https"
+		tlsConfig := &tls.Config{}
+		if backend.SNIDomain != "" {
+			tlsConfig.ServerName = backend.SNIDomain
+		}
+		client.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+	}
+
+	url := fmt.Sprintf("%s://%s:%d%s", scheme, host, backend.Port, backend.HealthCheckPath)
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("http check failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	expected := backend.ExpectedStatus
+	if expected == 0 {
+		expected = 200
+	}
+	if resp.StatusCode != expected {
+		return fmt.Errorf("unexpected status %d, want %d", resp.StatusCode, expected)
+	}
+	return nil
+}
+
+// SelectBackendHosts narrows backend.Hosts down to the ones currently marked healthy. A host
+// with no BackendHealth row yet hasn't been probed and is treated as healthy. If every host is
+// unhealthy and backend.FailOpen is set, it falls back to the full host list so the backend
+// doesn't go completely dark over a flapping health check.
+func SelectBackendHosts(db *gorm.DB, backend *Backend) ([]string, error) {
+	healthRows, err := listBackendHealth(db, backend.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	healthyByHost := make(map[string]bool, len(healthRows))
+	for _, row := range healthRows {
+		healthyByHost[row.Host] = row.Healthy
+	}
+
+	healthy := make([]string, 0, len(backend.Hosts))
+	for _, host := range backend.Hosts {
+		if known, ok := healthyByHost[host]; ok && !known {
+			continue
+		}
+		healthy = append(healthy, host)
+	}
+
+	if len(healthy) == 0 && backend.FailOpen {
+		return backend.Hosts, nil
+	}
+	return healthy, nil
+}
+
+// SelectBackendHost picks one host from candidates according to backend.LBPolicy. clientIP is
+// only consulted for LBIPHash; seq is a caller-maintained counter used to rotate round_robin
+// selection, since the backend's host list is otherwise stateless between calls.
+func SelectBackendHost(backend *Backend, candidates []string, clientIP string, seq uint64) (string, error) {
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no candidate hosts available for backend %s", backend.ID)
+	}
+
+	switch backend.LBPolicy {
+	case LBIPHash:
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(clientIP))
+		return candidates[h.Sum32()%uint32(len(candidates))], nil
+	case LBRandom:
+		return candidates[rand.IntN(len(candidates))], nil
+	case LBLeastConn:
+		// Without live connection counts from a dataplane to draw on, least_conn degrades to
+		// round_robin -- still spreads load evenly, just not connection-aware.
+		fallthrough
+	case LBRoundRobin, "":
+		return candidates[seq%uint64(len(candidates))], nil
+	default:
+		return "", fmt.Errorf("unknown lb policy: %s", backend.LBPolicy)
+	}
+}
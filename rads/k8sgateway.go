@@ -0,0 +1,865 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/google/uuid"
+	"github.com/mitchellh/hashstructure/v2"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayclientset "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned"
+	gatewayinformers "sigs.k8s.io/gateway-api/pkg/client/informers/externalversions"
+	gatewaylisters "sigs.k8s.io/gateway-api/pkg/client/listers/apis/v1"
+	gatewayalpha2listers "sigs.k8s.io/gateway-api/pkg/client/listers/apis/v1alpha2"
+	gatewaybeta1listers "sigs.k8s.io/gateway-api/pkg/client/listers/apis/v1beta1"
+)
+
+// k8sgateway.go implements an optional Kubernetes Gateway API provider: an alternative to NATS
+// ingestion that watches Gateway/HTTPRoute/TCPRoute/TLSRoute/ReferenceGrant and the Secrets they
+// reference via client-go informers, and turns what it sees into the same TLSCertificateUpsertV1,
+// IngressRuleUpsertV1 and HTTPRedirectRuleUpsertV1 events NATS producers send. Each translated
+// event is inserted as a Message row exactly like StoreMessage does, so it's picked up by
+// ProcessRequests and run through processMessage completely unmodified.
+//
+// TCPRoute and TLSRoute get the same translation: vessel's IngressRule doesn't distinguish
+// TLS-terminated from passthrough traffic at the listener/backend-selection layer modeled here,
+// and both route kinds only carry a single backendRefs list per rule, so only the first rule of
+// each is translated -- a route needing per-rule TCP load balancing isn't representable yet.
+// Cross-namespace references (a listener's certificateRefs, a route's backendRefs) are only
+// honored when a matching ReferenceGrant exists in the target namespace, per the Gateway API
+// contract; see referenceGrantAllows. After each reconcile, every watched Gateway/HTTPRoute/
+// TCPRoute/TLSRoute gets its Accepted status condition written back to the cluster so `kubectl
+// describe` reflects whether this provider could translate it.
+const defaultGatewayControllerName = "vessel.io/gateway-controller"
+
+// desiredResource is one translated vessel event, keyed so repeated reconciles can tell whether
+// it's new, changed, or gone (and therefore needs a delete).
+type desiredResource struct {
+	key           string
+	upsertEvent   string
+	upsertPayload interface{}
+	deleteEvent   string
+	deletePayload interface{}
+}
+
+func (d desiredResource) hash() uint64 {
+	h, err := hashstructure.Hash(d.upsertPayload, hashstructure.FormatV2, nil)
+	if err != nil {
+		return 0
+	}
+	return h
+}
+
+// K8sGatewayProvider watches Gateway API resources and emits vessel events for them.
+type K8sGatewayProvider struct {
+	manager       *Manager
+	kubeClient    kubernetes.Interface
+	gatewayClient gatewayclientset.Interface
+
+	gatewayLister        gatewaylisters.GatewayLister
+	httpRouteLister      gatewaylisters.HTTPRouteLister
+	tcpRouteLister       gatewayalpha2listers.TCPRouteLister
+	tlsRouteLister       gatewayalpha2listers.TLSRouteLister
+	secretLister         corelisters.SecretLister
+	referenceGrantLister gatewaybeta1listers.ReferenceGrantLister
+
+	// emitted tracks, per key, the hash of the last upsert sent (so a no-op resync from the
+	// informers doesn't re-insert a Message row) along with its delete event/payload, needed
+	// when the key later disappears from a reconcile and a *.delete has to be emitted for it.
+	emitted map[string]emittedResource
+
+	reconcileCh chan struct{}
+}
+
+type emittedResource struct {
+	hash          uint64
+	deleteEvent   string
+	deletePayload interface{}
+}
+
+func newK8sGatewayProvider(m *Manager) (*K8sGatewayProvider, error) {
+	restConfig, err := buildKubeRestConfig(m.Config.K8sGateway.Kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build kube config: %w", err)
+	}
+
+	kubeClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kube client: %w", err)
+	}
+
+	gatewayClient, err := gatewayclientset.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gateway-api client: %w", err)
+	}
+
+	return &K8sGatewayProvider{
+		manager:       m,
+		kubeClient:    kubeClient,
+		gatewayClient: gatewayClient,
+		emitted:       make(map[string]emittedResource),
+		reconcileCh:   make(chan struct{}, 1),
+	}, nil
+}
+
+func buildKubeRestConfig(kubeconfigPath string) (*rest.Config, error) {
+	if kubeconfigPath != "" {
+		return clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	}
+	return rest.InClusterConfig()
+}
+
+// referenceGrantAllows reports whether some ReferenceGrant in toNamespace permits fromKind objects
+// in fromNamespace to reference toKind objects there (optionally restricted to toName), per the
+// Gateway API rule that every cross-namespace reference must be covered by a grant.
+func (p *K8sGatewayProvider) referenceGrantAllows(fromNamespace, fromKind, toNamespace, toKind, toName string) bool {
+	grants, err := p.referenceGrantLister.ReferenceGrants(toNamespace).List(labels.Everything())
+	if err != nil {
+		return false
+	}
+
+	for _, grant := range grants {
+		fromMatches := false
+		for _, from := range grant.Spec.From {
+			if string(from.Kind) == fromKind && string(from.Namespace) == fromNamespace {
+				fromMatches = true
+				break
+			}
+		}
+		if !fromMatches {
+			continue
+		}
+
+		for _, to := range grant.Spec.To {
+			if string(to.Kind) != toKind {
+				continue
+			}
+			if to.Name == nil || string(*to.Name) == toName {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// controllerName is the value written into the ControllerName field of every status condition
+// this provider sets, so cluster operators running more than one Gateway controller can tell
+// whose write is whose.
+func (p *K8sGatewayProvider) controllerName() string {
+	if p.manager.Config.K8sGateway.ControllerName != "" {
+		return p.manager.Config.K8sGateway.ControllerName
+	}
+	return defaultGatewayControllerName
+}
+
+// requestReconcile coalesces bursts of informer events (e.g. the initial list) into a single
+// reconcile pass.
+func (p *K8sGatewayProvider) requestReconcile() {
+	select {
+	case p.reconcileCh <- struct{}{}:
+	default:
+	}
+}
+
+// run connects to the API server, starts informers, and reconciles until the manager's context
+// is cancelled. Connecting and the initial cache sync are retried with exponential backoff so a
+// temporarily-unreachable API server just delays startup instead of taking the provider down.
+func (p *K8sGatewayProvider) run() {
+	m := p.manager
+
+	gatewayFactory := gatewayinformers.NewSharedInformerFactory(p.gatewayClient, 30*time.Second)
+	coreFactory := informers.NewSharedInformerFactory(p.kubeClient, 30*time.Second)
+
+	gatewayInformer := gatewayFactory.Gateway().V1().Gateways().Informer()
+	httpRouteInformer := gatewayFactory.Gateway().V1().HTTPRoutes().Informer()
+	tcpRouteInformer := gatewayFactory.Gateway().V1alpha2().TCPRoutes().Informer()
+	tlsRouteInformer := gatewayFactory.Gateway().V1alpha2().TLSRoutes().Informer()
+	referenceGrantInformer := gatewayFactory.Gateway().V1beta1().ReferenceGrants().Informer()
+	secretInformer := coreFactory.Core().V1().Secrets().Informer()
+
+	p.gatewayLister = gatewayFactory.Gateway().V1().Gateways().Lister()
+	p.httpRouteLister = gatewayFactory.Gateway().V1().HTTPRoutes().Lister()
+	p.tcpRouteLister = gatewayFactory.Gateway().V1alpha2().TCPRoutes().Lister()
+	p.tlsRouteLister = gatewayFactory.Gateway().V1alpha2().TLSRoutes().Lister()
+	p.referenceGrantLister = gatewayFactory.Gateway().V1beta1().ReferenceGrants().Lister()
+	p.secretLister = coreFactory.Core().V1().Secrets().Lister()
+
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { p.requestReconcile() },
+		UpdateFunc: func(_, _ interface{}) { p.requestReconcile() },
+		DeleteFunc: func(interface{}) { p.requestReconcile() },
+	}
+	for _, informer := range []cache.SharedIndexInformer{gatewayInformer, httpRouteInformer, tcpRouteInformer, tlsRouteInformer, referenceGrantInformer, secretInformer} {
+		if _, err := informer.AddEventHandler(handler); err != nil {
+			fmt.Printf("K8sGateway: failed to register informer handler: %v\n", err)
+			return
+		}
+	}
+
+	stopCh := make(chan struct{})
+	go func() {
+		<-m.Context.Done()
+		close(stopCh)
+	}()
+
+	gatewayFactory.Start(stopCh)
+	coreFactory.Start(stopCh)
+
+	boff := backoff.NewExponentialBackOff()
+	boff.MaxElapsedTime = 0 // retry until the manager context is cancelled
+	err := backoff.Retry(func() error {
+		syncCtx, cancel := context.WithTimeout(m.Context, 30*time.Second)
+		defer cancel()
+		if !cache.WaitForCacheSync(syncCtx.Done(), gatewayInformer.HasSynced, httpRouteInformer.HasSynced, tcpRouteInformer.HasSynced, tlsRouteInformer.HasSynced, referenceGrantInformer.HasSynced, secretInformer.HasSynced) {
+			if m.Context.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("timed out waiting for informer caches to sync")
+		}
+		return nil
+	}, backoff.WithContext(boff, m.Context))
+	if err != nil {
+		fmt.Printf("K8sGateway: giving up on informer cache sync: %v\n", err)
+		return
+	}
+	if m.Context.Err() != nil {
+		return
+	}
+
+	p.reconcile()
+
+	for {
+		select {
+		case <-m.Context.Done():
+			return
+		case <-p.reconcileCh:
+			p.reconcile()
+		}
+	}
+}
+
+// reconcile rebuilds the full desired set of vessel events from the current informer caches,
+// emits upserts for anything new or changed, and deletes for anything that disappeared.
+func (p *K8sGatewayProvider) reconcile() {
+	gateways, err := p.gatewayLister.List(labels.Everything())
+	if err != nil {
+		fmt.Printf("K8sGateway: failed to list gateways: %v\n", err)
+		return
+	}
+	httpRoutes, err := p.httpRouteLister.List(labels.Everything())
+	if err != nil {
+		fmt.Printf("K8sGateway: failed to list httproutes: %v\n", err)
+		return
+	}
+	tcpRoutes, err := p.tcpRouteLister.List(labels.Everything())
+	if err != nil {
+		fmt.Printf("K8sGateway: failed to list tcproutes: %v\n", err)
+		return
+	}
+	tlsRoutes, err := p.tlsRouteLister.List(labels.Everything())
+	if err != nil {
+		fmt.Printf("K8sGateway: failed to list tlsroutes: %v\n", err)
+		return
+	}
+
+	desired := make(map[string]desiredResource)
+
+	for _, gw := range gateways {
+		for _, res := range p.translateGatewayListenerCertificates(gw) {
+			desired[res.key] = res
+		}
+		p.updateGatewayStatus(gw)
+	}
+	for _, route := range httpRoutes {
+		resources, accepted := p.translateHTTPRoute(gateways, route)
+		for _, res := range resources {
+			desired[res.key] = res
+		}
+		p.updateHTTPRouteStatus(route, accepted)
+	}
+	for _, route := range tcpRoutes {
+		res, ok := p.translateTCPRoute(gateways, route)
+		if ok {
+			desired[res.key] = res
+		}
+		p.updateTCPRouteStatus(route, ok)
+	}
+	for _, route := range tlsRoutes {
+		res, ok := p.translateTLSRoute(gateways, route)
+		if ok {
+			desired[res.key] = res
+		}
+		p.updateTLSRouteStatus(route, ok)
+	}
+
+	p.applyDesired(desired)
+}
+
+// routeAcceptedCondition builds the Accepted condition every route status entry carries,
+// reflecting whether translateHTTPRoute/translateTCPRoute/translateTLSRoute produced anything for
+// this route.
+func routeAcceptedCondition(generation int64, accepted bool) metav1.Condition {
+	cond := metav1.Condition{
+		Type:               string(gatewayv1.RouteConditionAccepted),
+		ObservedGeneration: generation,
+		Status:             metav1.ConditionTrue,
+		Reason:             string(gatewayv1.RouteReasonAccepted),
+		Message:            "Route was translated and applied by the vessel Gateway API provider",
+	}
+	if !accepted {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = string(gatewayv1.RouteReasonNoMatchingParent)
+		cond.Message = "No Gateway listener in this route's parentRefs could be translated"
+	}
+	return cond
+}
+
+// routeParentStatuses rebuilds a route's per-parentRef status, preserving any conditions set by
+// other controllers on each parent and only touching the Accepted condition this provider owns.
+func (p *K8sGatewayProvider) routeParentStatuses(existing []gatewayv1.RouteParentStatus, parentRefs []gatewayv1.ParentReference, generation int64, accepted bool) ([]gatewayv1.RouteParentStatus, bool) {
+	cond := routeAcceptedCondition(generation, accepted)
+	controllerName := gatewayv1.GatewayController(p.controllerName())
+
+	changed := len(existing) != len(parentRefs)
+	parents := make([]gatewayv1.RouteParentStatus, len(parentRefs))
+	for i, parentRef := range parentRefs {
+		var conditions []metav1.Condition
+		for _, e := range existing {
+			if e.ControllerName == controllerName && e.ParentRef == parentRef {
+				conditions = append(conditions, e.Conditions...)
+				break
+			}
+		}
+		if apimeta.SetStatusCondition(&conditions, cond) {
+			changed = true
+		}
+		parents[i] = gatewayv1.RouteParentStatus{
+			ParentRef:      parentRef,
+			ControllerName: controllerName,
+			Conditions:     conditions,
+		}
+	}
+	return parents, changed
+}
+
+// updateGatewayStatus writes an Accepted condition reflecting whether gw has any listeners,
+// skipping the UpdateStatus call entirely when nothing would change.
+func (p *K8sGatewayProvider) updateGatewayStatus(gw *gatewayv1.Gateway) {
+	cond := metav1.Condition{
+		Type:               string(gatewayv1.GatewayConditionAccepted),
+		ObservedGeneration: gw.Generation,
+		Status:             metav1.ConditionTrue,
+		Reason:             string(gatewayv1.GatewayReasonAccepted),
+		Message:            "Gateway was accepted by the vessel Gateway API provider",
+	}
+	if len(gw.Spec.Listeners) == 0 {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = string(gatewayv1.GatewayReasonListenersNotValid)
+		cond.Message = "Gateway has no listeners"
+	}
+
+	updated := gw.DeepCopy()
+	if !apimeta.SetStatusCondition(&updated.Status.Conditions, cond) {
+		return
+	}
+
+	if _, err := p.gatewayClient.GatewayV1().Gateways(gw.Namespace).UpdateStatus(p.manager.Context, updated, metav1.UpdateOptions{}); err != nil {
+		fmt.Printf("K8sGateway: failed to update Gateway %s/%s status: %v\n", gw.Namespace, gw.Name, err)
+	}
+}
+
+// updateHTTPRouteStatus writes route.Status.Parents[*].Conditions["Accepted"] for an HTTPRoute.
+func (p *K8sGatewayProvider) updateHTTPRouteStatus(route *gatewayv1.HTTPRoute, accepted bool) {
+	parents, changed := p.routeParentStatuses(route.Status.Parents, route.Spec.ParentRefs, route.Generation, accepted)
+	if !changed {
+		return
+	}
+
+	updated := route.DeepCopy()
+	updated.Status.Parents = parents
+	if _, err := p.gatewayClient.GatewayV1().HTTPRoutes(route.Namespace).UpdateStatus(p.manager.Context, updated, metav1.UpdateOptions{}); err != nil {
+		fmt.Printf("K8sGateway: failed to update HTTPRoute %s/%s status: %v\n", route.Namespace, route.Name, err)
+	}
+}
+
+// updateTCPRouteStatus writes route.Status.Parents[*].Conditions["Accepted"] for a TCPRoute.
+func (p *K8sGatewayProvider) updateTCPRouteStatus(route *gatewayv1alpha2.TCPRoute, accepted bool) {
+	parents, changed := p.routeParentStatuses(route.Status.Parents, route.Spec.ParentRefs, route.Generation, accepted)
+	if !changed {
+		return
+	}
+
+	updated := route.DeepCopy()
+	updated.Status.Parents = parents
+	if _, err := p.gatewayClient.GatewayV1alpha2().TCPRoutes(route.Namespace).UpdateStatus(p.manager.Context, updated, metav1.UpdateOptions{}); err != nil {
+		fmt.Printf("K8sGateway: failed to update TCPRoute %s/%s status: %v\n", route.Namespace, route.Name, err)
+	}
+}
+
+// updateTLSRouteStatus writes route.Status.Parents[*].Conditions["Accepted"] for a TLSRoute.
+func (p *K8sGatewayProvider) updateTLSRouteStatus(route *gatewayv1alpha2.TLSRoute, accepted bool) {
+	parents, changed := p.routeParentStatuses(route.Status.Parents, route.Spec.ParentRefs, route.Generation, accepted)
+	if !changed {
+		return
+	}
+
+	updated := route.DeepCopy()
+	updated.Status.Parents = parents
+	if _, err := p.gatewayClient.GatewayV1alpha2().TLSRoutes(route.Namespace).UpdateStatus(p.manager.Context, updated, metav1.UpdateOptions{}); err != nil {
+		fmt.Printf("K8sGateway: failed to update TLSRoute %s/%s status: %v\n", route.Namespace, route.Name, err)
+	}
+}
+
+// applyDesired emits an upsert Message for every desired resource whose hash changed since the
+// last reconcile, and a delete Message for every previously-emitted key that's no longer desired.
+func (p *K8sGatewayProvider) applyDesired(desired map[string]desiredResource) {
+	for key, res := range desired {
+		h := res.hash()
+		if existing, ok := p.emitted[key]; ok && existing.hash == h {
+			continue
+		}
+		if p.emit(res.upsertEvent, res.upsertPayload) {
+			p.emitted[key] = emittedResource{hash: h, deleteEvent: res.deleteEvent, deletePayload: res.deletePayload}
+		}
+	}
+
+	for key, existing := range p.emitted {
+		if _, ok := desired[key]; ok {
+			continue
+		}
+		if p.emit(existing.deleteEvent, existing.deletePayload) {
+			delete(p.emitted, key)
+		}
+	}
+}
+
+// emit inserts a Message row for event/payload, the same way StoreMessage does for NATS-delivered
+// events, so it flows through ProcessRequests and processMessage unmodified.
+func (p *K8sGatewayProvider) emit(event string, payload interface{}) bool {
+	m := p.manager
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		fmt.Printf("K8sGateway: failed to marshal %s payload: %v\n", event, err)
+		return false
+	}
+
+	now := time.Now().UTC()
+	msgEntry := Message{
+		Event:           event,
+		RequestID:       uuid.NewString(),
+		RequestPayload:  string(payloadJSON),
+		ResponsePayload: "{}",
+		Processed:       false,
+		Replied:         false,
+		RequestedAt:     &now,
+		QueuedAt:        &now,
+		ProcessedAt:     nil,
+	}
+
+	if err := m.ReadWriteDB.Create(&msgEntry).Error; err != nil {
+		fmt.Printf("K8sGateway: failed to queue %s: %v\n", event, err)
+		return false
+	}
+	return true
+}
+
+func commonEventParams() CommonEventParamsV1 {
+	return CommonEventParamsV1{
+		RequestID:   uuid.NewString(),
+		RequestedAt: time.Now().UTC(),
+	}
+}
+
+// translateGatewayListenerCertificates turns every TLS-terminating listener's certificateRefs
+// into TLSCertificateUpsertV1 events, reading the referenced Secret's tls.crt/tls.key.
+func (p *K8sGatewayProvider) translateGatewayListenerCertificates(gw *gatewayv1.Gateway) []desiredResource {
+	var out []desiredResource
+
+	for _, l := range gw.Spec.Listeners {
+		if l.TLS == nil || (l.TLS.Mode != nil && *l.TLS.Mode == gatewayv1.TLSModePassthrough) {
+			continue
+		}
+
+		for _, ref := range l.TLS.CertificateRefs {
+			if ref.Kind != nil && *ref.Kind != "Secret" {
+				continue
+			}
+			namespace := gw.Namespace
+			if ref.Namespace != nil {
+				namespace = string(*ref.Namespace)
+			}
+			if namespace != gw.Namespace && !p.referenceGrantAllows(gw.Namespace, "Gateway", namespace, "Secret", string(ref.Name)) {
+				continue
+			}
+
+			secret, err := p.secretLister.Secrets(namespace).Get(string(ref.Name))
+			if err != nil {
+				continue // Secret not synced / doesn't exist yet; next reconcile will retry.
+			}
+			if secret.Type != corev1.SecretTypeTLS {
+				continue
+			}
+
+			domain := "*"
+			if l.Hostname != nil && string(*l.Hostname) != "" {
+				domain = string(*l.Hostname)
+			}
+
+			upsert := TLSCertificateUpsertV1{
+				CommonEventParamsV1: commonEventParams(),
+				IsWildcard:          isWildcardDomain(domain),
+				Domain:              domain,
+				Cert:                string(secret.Data["tls.crt"]),
+				Key:                 string(secret.Data["tls.key"]),
+			}
+
+			out = append(out, desiredResource{
+				key:           fmt.Sprintf("tls/%s/%s/%s", gw.Namespace, gw.Name, l.Name),
+				upsertEvent:   "v1.tls_certificate.upsert",
+				upsertPayload: upsert,
+				deleteEvent:   "v1.tls_certificate.delete",
+				deletePayload: TLSCertificateDeleteV1{Domain: domain, IsWildcard: upsert.IsWildcard},
+			})
+		}
+	}
+
+	return out
+}
+
+func isWildcardDomain(domain string) bool {
+	return domain == "*" || len(domain) > 2 && domain[0] == '*' && domain[1] == '.'
+}
+
+// gatewayListenerFor finds the Gateway+Listener a ParentReference points at.
+func gatewayListenerFor(gateways []*gatewayv1.Gateway, routeNamespace string, ref gatewayv1.ParentReference) (*gatewayv1.Gateway, *gatewayv1.Listener) {
+	namespace := routeNamespace
+	if ref.Namespace != nil {
+		namespace = string(*ref.Namespace)
+	}
+
+	for _, gw := range gateways {
+		if gw.Namespace != namespace || gw.Name != string(ref.Name) {
+			continue
+		}
+		if ref.SectionName == nil {
+			if len(gw.Spec.Listeners) > 0 {
+				return gw, &gw.Spec.Listeners[0]
+			}
+			return gw, nil
+		}
+		for i := range gw.Spec.Listeners {
+			if gw.Spec.Listeners[i].Name == *ref.SectionName {
+				return gw, &gw.Spec.Listeners[i]
+			}
+		}
+	}
+	return nil, nil
+}
+
+// translateHTTPRoute produces one IngressRuleUpsertV1 or HTTPRedirectRuleUpsertV1 per
+// (bound listener) x (hostname) x (rule) x (match) combination. The second return value reports
+// whether at least one listener accepted this route, which is what updateHTTPRouteStatus's
+// Accepted condition reflects back to the cluster.
+func (p *K8sGatewayProvider) translateHTTPRoute(gateways []*gatewayv1.Gateway, route *gatewayv1.HTTPRoute) ([]desiredResource, bool) {
+	var out []desiredResource
+	accepted := false
+
+	hostnames := make([]string, 0, len(route.Spec.Hostnames))
+	for _, h := range route.Spec.Hostnames {
+		hostnames = append(hostnames, string(h))
+	}
+
+	for _, parentRef := range route.Spec.ParentRefs {
+		gw, listener := gatewayListenerFor(gateways, route.Namespace, parentRef)
+		if gw == nil || listener == nil {
+			continue
+		}
+		if listener.Protocol != gatewayv1.HTTPProtocolType && listener.Protocol != gatewayv1.HTTPSProtocolType {
+			continue
+		}
+		accepted = true
+
+		domains := hostnames
+		if len(domains) == 0 {
+			domain := "*"
+			if listener.Hostname != nil && string(*listener.Hostname) != "" {
+				domain = string(*listener.Hostname)
+			}
+			domains = []string{domain}
+		}
+
+		for ruleIdx, rule := range route.Spec.Rules {
+			matches := rule.Matches
+			if len(matches) == 0 {
+				matches = []gatewayv1.HTTPRouteMatch{{}}
+			}
+
+			redirectFilter := findRequestRedirectFilter(rule.Filters)
+
+			for matchIdx, match := range matches {
+				routePrefix := "/"
+				if match.Path != nil && match.Path.Value != nil {
+					routePrefix = *match.Path.Value
+				}
+
+				for _, domain := range domains {
+					key := fmt.Sprintf("httproute/%s/%s/%s/%s/%s/%d/%d/%s", route.Namespace, route.Name, gw.Namespace, gw.Name, listener.Name, ruleIdx, matchIdx, domain)
+
+					if redirectFilter != nil {
+						res := p.translateRequestRedirect(gw, listener, domain, routePrefix, redirectFilter, key)
+						out = append(out, res)
+						continue
+					}
+
+					if len(rule.BackendRefs) == 0 {
+						continue
+					}
+
+					res, ok := p.translateHTTPBackendRefs(route, gw, listener, domain, routePrefix, rule.BackendRefs, key)
+					if ok {
+						out = append(out, res)
+					}
+				}
+			}
+		}
+	}
+
+	return out, accepted
+}
+
+func findRequestRedirectFilter(filters []gatewayv1.HTTPRouteFilter) *gatewayv1.HTTPRequestRedirectFilter {
+	for _, f := range filters {
+		if f.Type == gatewayv1.HTTPRouteFilterRequestRedirect && f.RequestRedirect != nil {
+			return f.RequestRedirect
+		}
+	}
+	return nil
+}
+
+func (p *K8sGatewayProvider) translateRequestRedirect(gw *gatewayv1.Gateway, listener *gatewayv1.Listener, domain string, routePrefix string, filter *gatewayv1.HTTPRequestRedirectFilter, key string) desiredResource {
+	statusCode := 302
+	if filter.StatusCode != nil {
+		statusCode = *filter.StatusCode
+	}
+
+	var scheme, host, path string
+	if filter.Scheme != nil {
+		scheme = *filter.Scheme
+	}
+	if filter.Hostname != nil {
+		host = string(*filter.Hostname)
+	}
+	if filter.Path != nil && filter.Path.ReplaceFullPath != nil {
+		path = *filter.Path.ReplaceFullPath
+	}
+
+	isHTTPSUpgrade := scheme == "https" && host == "" && path == ""
+
+	upsert := HTTPRedirectRuleUpsertV1{
+		CommonEventParamsV1: commonEventParams(),
+		BindIP:              "0.0.0.0",
+		Port:                int(listener.Port),
+		IsTLS:               listener.Protocol == gatewayv1.HTTPSProtocolType,
+		Domain:              domain,
+		RoutePrefix:         routePrefix,
+		IsHttpsRedirect:     isHTTPSUpgrade,
+		SchemeRedirect:      scheme,
+		HostRedirect:        host,
+		PathRedirect:        path,
+		StatusCode:          statusCode,
+	}
+
+	return desiredResource{
+		key:           key,
+		upsertEvent:   "v1.http_redirect_rule.upsert",
+		upsertPayload: upsert,
+		deleteEvent:   "v1.http_redirect_rule.delete",
+		deletePayload: HTTPRedirectRuleDeleteV1{
+			BindIP: upsert.BindIP, Port: upsert.Port, Domain: domain, RoutePrefix: routePrefix,
+			IsHttpsRedirect: upsert.IsHttpsRedirect,
+		},
+	}
+}
+
+func (p *K8sGatewayProvider) translateHTTPBackendRefs(route *gatewayv1.HTTPRoute, gw *gatewayv1.Gateway, listener *gatewayv1.Listener, domain string, routePrefix string, backendRefs []gatewayv1.HTTPBackendRef, key string) (desiredResource, bool) {
+	if len(backendRefs) == 0 {
+		return desiredResource{}, false
+	}
+
+	ref := backendRefs[0].BackendRef
+	host, port, ok := p.resolveServiceBackend(route.Namespace, "HTTPRoute", ref)
+	if !ok {
+		return desiredResource{}, false
+	}
+
+	upsert := IngressRuleUpsertV1{
+		CommonEventParamsV1: commonEventParams(),
+		BindIP:              "0.0.0.0",
+		Port:                int(listener.Port),
+		Protocol:            HTTP,
+		IsTLS:               listener.Protocol == gatewayv1.HTTPSProtocolType,
+		Domain:              domain,
+		RoutePrefix:         routePrefix,
+		BackendResolver:     STATIC_RESOLVER,
+		BackendHosts:        []string{host},
+		BackendPort:         port,
+	}
+
+	return desiredResource{
+		key:           key,
+		upsertEvent:   "v1.ingress_rule.upsert",
+		upsertPayload: upsert,
+		deleteEvent:   "v1.ingress_rule.delete",
+		deletePayload: IngressRuleDeleteV1{
+			BindIP: upsert.BindIP, Port: upsert.Port, Protocol: HTTP, Domain: domain, RoutePrefix: routePrefix,
+		},
+	}, true
+}
+
+// resolveServiceBackend maps a Gateway API Service backendRef to a (host, port) pair reachable
+// over cluster DNS. routeKind is the referencing route's kind ("HTTPRoute", "TCPRoute", or
+// "TLSRoute"), needed to match a cross-namespace ref against ReferenceGrant.Spec.From.Kind: a ref
+// into another namespace is only honored when a ReferenceGrant there permits it.
+func (p *K8sGatewayProvider) resolveServiceBackend(routeNamespace string, routeKind string, ref gatewayv1.BackendRef) (string, int, bool) {
+	if ref.Kind != nil && *ref.Kind != "Service" {
+		return "", 0, false
+	}
+	namespace := routeNamespace
+	if ref.Namespace != nil {
+		namespace = string(*ref.Namespace)
+	}
+	if ref.Port == nil {
+		return "", 0, false
+	}
+	if namespace != routeNamespace && !p.referenceGrantAllows(routeNamespace, routeKind, namespace, "Service", string(ref.Name)) {
+		return "", 0, false
+	}
+	host := fmt.Sprintf("%s.%s.svc.cluster.local", ref.Name, namespace)
+	return host, int(*ref.Port), true
+}
+
+// translateTCPRoute maps a TCPRoute's first rule onto a TCP IngressRule bound to the matched
+// listener's port.
+func (p *K8sGatewayProvider) translateTCPRoute(gateways []*gatewayv1.Gateway, route *gatewayv1alpha2.TCPRoute) (desiredResource, bool) {
+	if len(route.Spec.Rules) == 0 {
+		return desiredResource{}, false
+	}
+	rule := route.Spec.Rules[0]
+	if len(rule.BackendRefs) == 0 {
+		return desiredResource{}, false
+	}
+
+	for _, parentRef := range route.Spec.ParentRefs {
+		gw, listener := gatewayListenerFor(gateways, route.Namespace, parentRef)
+		if gw == nil || listener == nil || listener.Protocol != gatewayv1.TCPProtocolType {
+			continue
+		}
+
+		host, port, ok := p.resolveServiceBackend(route.Namespace, "TCPRoute", rule.BackendRefs[0])
+		if !ok {
+			continue
+		}
+
+		upsert := IngressRuleUpsertV1{
+			CommonEventParamsV1: commonEventParams(),
+			BindIP:              "0.0.0.0",
+			Port:                int(listener.Port),
+			Protocol:            TCP,
+			BackendResolver:     STATIC_RESOLVER,
+			BackendHosts:        []string{host},
+			BackendPort:         port,
+		}
+
+		key := fmt.Sprintf("tcproute/%s/%s", route.Namespace, route.Name)
+		return desiredResource{
+			key:           key,
+			upsertEvent:   "v1.ingress_rule.upsert",
+			upsertPayload: upsert,
+			deleteEvent:   "v1.ingress_rule.delete",
+			deletePayload: IngressRuleDeleteV1{BindIP: upsert.BindIP, Port: upsert.Port, Protocol: TCP},
+		}, true
+	}
+
+	return desiredResource{}, false
+}
+
+// translateTLSRoute gets the same TCP-listener translation as translateTCPRoute; see the package
+// doc comment for why.
+func (p *K8sGatewayProvider) translateTLSRoute(gateways []*gatewayv1.Gateway, route *gatewayv1alpha2.TLSRoute) (desiredResource, bool) {
+	if len(route.Spec.Rules) == 0 {
+		return desiredResource{}, false
+	}
+	rule := route.Spec.Rules[0]
+	if len(rule.BackendRefs) == 0 {
+		return desiredResource{}, false
+	}
+
+	for _, parentRef := range route.Spec.ParentRefs {
+		gw, listener := gatewayListenerFor(gateways, route.Namespace, parentRef)
+		if gw == nil || listener == nil || listener.Protocol != gatewayv1.TLSProtocolType {
+			continue
+		}
+
+		host, port, ok := p.resolveServiceBackend(route.Namespace, "TLSRoute", rule.BackendRefs[0])
+		if !ok {
+			continue
+		}
+
+		upsert := IngressRuleUpsertV1{
+			CommonEventParamsV1: commonEventParams(),
+			BindIP:              "0.0.0.0",
+			Port:                int(listener.Port),
+			Protocol:            TCP,
+			BackendResolver:     STATIC_RESOLVER,
+			BackendHosts:        []string{host},
+			BackendPort:         port,
+		}
+
+		key := fmt.Sprintf("tlsroute/%s/%s", route.Namespace, route.Name)
+		return desiredResource{
+			key:           key,
+			upsertEvent:   "v1.ingress_rule.upsert",
+			upsertPayload: upsert,
+			deleteEvent:   "v1.ingress_rule.delete",
+			deletePayload: IngressRuleDeleteV1{BindIP: upsert.BindIP, Port: upsert.Port, Protocol: TCP},
+		}, true
+	}
+
+	return desiredResource{}, false
+}
+
+// K8sGatewayProviderServer brings up the Kubernetes Gateway API provider as one of Manager's
+// long-running goroutines. It's a no-op unless Config.K8sGateway is set and enabled, so
+// deployments that only want NATS-delivered events don't need cluster credentials at all.
+func (m *Manager) K8sGatewayProviderServer() {
+	m.Wg.Add(1)
+	defer m.Wg.Done()
+
+	if m.Config.K8sGateway == nil || !m.Config.K8sGateway.Enabled {
+		return
+	}
+
+	provider, err := newK8sGatewayProvider(m)
+	if err != nil {
+		fmt.Printf("K8sGateway: failed to initialize provider: %v\n", err)
+		return
+	}
+
+	provider.run()
+}
@@ -23,6 +23,17 @@ func main() {
 	go manager.StoreRequestsAndAcknowledge()
 	go manager.ProcessRequests()
 	go manager.SendResponsesToQueue()
+	go manager.ACMERenewer()
+	go manager.DevCAServer()
+	go manager.HealthChecker()
+	go manager.PoolHealthChecker()
+	go manager.BackendHealthServer()
+	go manager.ConfigSnapshotServer()
+	go manager.ListenForBroadcastChangesToProxies()
+	go manager.ADSServer()
+	go manager.K8sGatewayProviderServer()
+	go manager.AdminAPIServer()
+	go manager.ClusterAPIServer()
 
 	// Wait for signal
 	sig := <-sigChan
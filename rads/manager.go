@@ -5,13 +5,89 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/cenkalti/backoff/v4"
 	"github.com/nats-io/nats.go"
 	"gorm.io/gorm"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 )
 
+const (
+	defaultNatsRetryInitialInterval = 500 * time.Millisecond
+	defaultNatsRetryMaxInterval     = 30 * time.Second
+	defaultNatsRetryMaxElapsed      = 5 * time.Minute
+	defaultNatsPublishBatchSize     = 200
+
+	// idlePollInterval is how long ProcessRequests/SendResponsesToQueue sleep when they find
+	// nothing to do -- this is normal idle behavior, not a failure, so it isn't backed off.
+	idlePollInterval = 1 * time.Second
+)
+
+// newNatsRetryBackoff builds the ExponentialBackOff ListenToStream and SendResponsesToQueue use
+// while retrying a failed NATS connection attempt, so a flapping server gets backed off instead
+// of hammered. Call Reset() on the returned backoff once a connection succeeds.
+func newNatsRetryBackoff(cfg *NatsConfig) *backoff.ExponentialBackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = durationOrDefault(cfg.RetryInitialIntervalMs, defaultNatsRetryInitialInterval)
+	b.MaxInterval = durationOrDefault(cfg.RetryMaxIntervalMs, defaultNatsRetryMaxInterval)
+	b.MaxElapsedTime = minutesOrDefault(cfg.RetryMaxElapsedMinutes, defaultNatsRetryMaxElapsed)
+	return b
+}
+
+// newRetryBackoff builds the ExponentialBackOff ProcessRequests uses while retrying a failing DB
+// round, using the same defaults as newNatsRetryBackoff since there's no dedicated config surface
+// for DB retry tuning.
+func newRetryBackoff() *backoff.ExponentialBackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = defaultNatsRetryInitialInterval
+	b.MaxInterval = defaultNatsRetryMaxInterval
+	b.MaxElapsedTime = defaultNatsRetryMaxElapsed
+	return b
+}
+
+func durationOrDefault(ms int, def time.Duration) time.Duration {
+	if ms <= 0 {
+		return def
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+func minutesOrDefault(minutes int, def time.Duration) time.Duration {
+	if minutes <= 0 {
+		return def
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+func secondsOrDefault(seconds int, def time.Duration) time.Duration {
+	if seconds <= 0 {
+		return def
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// sleepBackoff waits out the next backoff interval, or until ctx is cancelled, whichever comes
+// first. It reports whether the caller should keep retrying. If b's MaxElapsedTime has been
+// exhausted, it resets b and waits out a fresh InitialInterval rather than giving up -- these
+// loops retry for the lifetime of the Manager, so MaxElapsedTime only bounds how long a single
+// run of failures is allowed to keep growing the wait, not the number of attempts.
+func sleepBackoff(ctx context.Context, b *backoff.ExponentialBackOff) bool {
+	wait := b.NextBackOff()
+	if wait == backoff.Stop {
+		b.Reset()
+		wait = b.NextBackOff()
+	}
+	select {
+	case <-time.After(wait):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 type Manager struct {
 	Config *Config
 
@@ -21,10 +97,38 @@ type Manager struct {
 	OutgoingStreamPrefix string
 	NATSMessageChan      chan *nats.Msg
 
-	HasPendingProxyChanges atomic.Bool
+	// resourceVersions tracks a pending-change flag and a version counter per ADS resource type
+	// (see ads.go): ListenForBroadcastChangesToProxies bumps a type's version whenever
+	// BroadcastChangesToProxies marks it pending, and each open ADS stream compares its own
+	// per-type cursor against that version to decide whether to push a fresh snapshot of just
+	// that type.
+	resourceVersions *resourceVersionTracker
+
+	DevCA *DevCA
+
+	// Completions lets the admin API's synchronous handlers (see adminapi.go) block on a specific
+	// RequestID and be woken up the moment ProcessRequests commits that Message's result.
+	Completions *pendingCompletions
 
 	ReadOnlyDB  *gorm.DB
 	ReadWriteDB *gorm.DB
+	// ReadOnlyDBClose/ReadWriteDBClose close the above connections. reopenDatabases uses them
+	// before swapping in fresh ones, e.g. after a Raft snapshot restore (see clusterstore.go).
+	ReadOnlyDBClose  func()
+	ReadWriteDBClose func()
+
+	// PoolHealth tracks the live up/down state of each IngressRuleBackend pool, probed by
+	// PoolHealthChecker (see poolhealth.go). Unlike BackendHealth, this is in-memory only.
+	PoolHealth *PoolHealthTracker
+
+	// Cluster, when Config.Cluster is set, replicates mutations via Raft across HA peers (see
+	// clusterstore.go) instead of each node applying NATS/admin API writes independently.
+	Cluster *ClusterStore
+
+	// Metrics instruments the message pipeline (see pipelinemetrics.go). Always created, since the
+	// pipeline goroutines always run regardless of whether AdminAPIServer mounts its registry at
+	// /metrics.
+	Metrics *PipelineMetrics
 
 	Wg            *sync.WaitGroup
 	Context       context.Context
@@ -58,7 +162,7 @@ func NewManager() (*Manager, error) {
 	// Create the context and cancel the function.
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &Manager{
+	m := &Manager{
 		Config: config,
 
 		IncomingStream:       "proxy." + config.AgentID + ".request.>",
@@ -69,15 +173,90 @@ func NewManager() (*Manager, error) {
 
 		NATSMessageChan: make(chan *nats.Msg, 1000),
 
-		HasPendingProxyChanges: atomic.Bool{},
+		resourceVersions: newResourceVersionTracker(),
+
+		Completions: newPendingCompletions(),
 
-		ReadOnlyDB:  readOnlyDB,
-		ReadWriteDB: readWriteDB,
+		ReadOnlyDB:       readOnlyDB,
+		ReadWriteDB:      readWriteDB,
+		ReadOnlyDBClose:  readOnlyDBClose,
+		ReadWriteDBClose: readWriteDBClose,
+
+		PoolHealth: newPoolHealthTracker(),
+
+		Metrics: newPipelineMetrics(readOnlyDB),
 
 		Wg:            &sync.WaitGroup{},
 		Context:       ctx,
 		CancelContext: cancel,
-	}, nil
+	}
+
+	if config.DevCA {
+		devCA, err := m.EnsureDevCA()
+		if err != nil {
+			readWriteDBClose()
+			readOnlyDBClose()
+			return nil, fmt.Errorf("failed to init manager: %w", err)
+		}
+		m.DevCA = devCA
+	}
+
+	if config.Cluster != nil {
+		cluster, err := newClusterStore(m)
+		if err != nil {
+			readWriteDBClose()
+			readOnlyDBClose()
+			return nil, fmt.Errorf("failed to init manager: %w", err)
+		}
+		m.Cluster = cluster
+	}
+
+	return m, nil
+}
+
+// reopenDatabases closes both DB connections and opens fresh ones against the same
+// DatabaseFilePath, re-running AutoMigrate. Used by restoreDatabaseFile after a Raft snapshot
+// restore replaces the file out from under the existing connections.
+func (m *Manager) reopenDatabases() error {
+	readOnlyDB, readOnlyDBClose, err := openSQLite(m.Config.DatabaseFilePath, false)
+	if err != nil {
+		return fmt.Errorf("failed to reopen read-only database: %w", err)
+	}
+	readWriteDB, readWriteDBClose, err := openSQLite(m.Config.DatabaseFilePath, true)
+	if err != nil {
+		readOnlyDBClose()
+		return fmt.Errorf("failed to reopen read-write database: %w", err)
+	}
+	if err := MigrateTables(readWriteDB); err != nil {
+		readOnlyDBClose()
+		readWriteDBClose()
+		return fmt.Errorf("failed to migrate reopened database: %w", err)
+	}
+
+	m.ReadOnlyDB = readOnlyDB
+	m.ReadOnlyDBClose = readOnlyDBClose
+	m.ReadWriteDB = readWriteDB
+	m.ReadWriteDBClose = readWriteDBClose
+	return nil
+}
+
+// restoreDatabaseFile replaces the SQLite file backing both DB connections with data and reopens
+// them -- see clusterFSM.Restore in clusterstore.go. Both connections are closed first since
+// sqlite keeps prepared statements and page cache that a file changed out from under it would
+// otherwise leave stale.
+func (m *Manager) restoreDatabaseFile(data []byte) error {
+	if m.ReadWriteDBClose != nil {
+		m.ReadWriteDBClose()
+	}
+	if m.ReadOnlyDBClose != nil {
+		m.ReadOnlyDBClose()
+	}
+
+	if err := os.WriteFile(m.Config.DatabaseFilePath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write restored database file: %w", err)
+	}
+
+	return m.reopenDatabases()
 }
 
 func (m *Manager) ParseEventTypeFromSubject(subject string) string {
@@ -114,10 +293,23 @@ func (m *Manager) ListenToStream() {
 		}
 	}()
 
+	// In clustered mode, only the Raft leader consumes NATS -- followers would otherwise each
+	// create their own durable consumer and race to process the same messages independently.
+	// Followers wait here until this node becomes leader (or the cluster config is removed).
+	// Note: if leadership is lost after this point, the subscription is not torn down early; it
+	// keeps running until the context is cancelled.
+	for m.Cluster != nil && !m.Cluster.IsLeader() {
+		if m.Context.Err() != nil {
+			return
+		}
+		time.Sleep(idlePollInterval)
+	}
+
 	// NATS Server could be down, so we need to wait until it comes up
 	// Because, it's very important for Regional ADS to be online
 	// So that, it can serve proxies required configs
 	// If NATS Server become unavailable, at max that should pause updates
+	retryBackoff := newNatsRetryBackoff(&m.Config.NatsConfig)
 	for {
 
 		// check context deadline
@@ -128,7 +320,9 @@ func (m *Manager) ListenToStream() {
 		natsConn, err = m.CreateNATSConnection()
 		if err != nil {
 			fmt.Printf("Failed to connect to NATS Server: %v\n", err)
-			time.Sleep(5 * time.Second)
+			if !sleepBackoff(m.Context, retryBackoff) {
+				return
+			}
 			continue
 		}
 
@@ -138,7 +332,9 @@ func (m *Manager) ListenToStream() {
 			natsConn.Close()
 			natsConn = nil
 			fmt.Printf("Failed to create Jet Stream context: %v\n", err)
-			time.Sleep(5 * time.Second)
+			if !sleepBackoff(m.Context, retryBackoff) {
+				return
+			}
 			continue
 		}
 
@@ -157,9 +353,12 @@ func (m *Manager) ListenToStream() {
 			natsConn.Close()
 			natsConn = nil
 			fmt.Printf("Failed to subscribe to NATS Stream: %v\n", err)
-			time.Sleep(5 * time.Second)
+			if !sleepBackoff(m.Context, retryBackoff) {
+				return
+			}
 			continue
 		}
+		retryBackoff.Reset()
 		break
 	}
 
@@ -213,6 +412,37 @@ func (m *Manager) IsMessageExist(event string, requestID string) (bool, error) {
 	return true, nil
 }
 
+// enqueueMessage inserts a new Message row for event/requestID, or returns the existing row
+// unmodified (isNew false) if one was already queued -- this is the dedup-by-RequestID step
+// shared by StoreMessage (NATS) and the admin API's synchronous handlers (see adminapi.go), so
+// both ingestion paths feed the exact same ProcessRequests/processMessage pipeline identically.
+func (m *Manager) enqueueMessage(event string, requestID string, requestedAt time.Time, requestPayload []byte) (msg *Message, isNew bool, err error) {
+	existing, err := getMessageByEventAndRequestID(m.ReadOnlyDB, event, requestID)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		return existing, false, nil
+	}
+
+	currentTime := time.Now().UTC()
+	msg = &Message{
+		Event:           event,
+		RequestID:       requestID,
+		RequestPayload:  string(requestPayload),
+		ResponsePayload: "{}",
+		Processed:       false,
+		Replied:         false,
+		RequestedAt:     &requestedAt,
+		QueuedAt:        &currentTime,
+		ProcessedAt:     nil,
+	}
+	if err := m.ReadWriteDB.Create(msg).Error; err != nil {
+		return nil, false, err
+	}
+	return msg, true, nil
+}
+
 func (m *Manager) StoreMessage(msg *nats.Msg) {
 	event := m.ParseEventTypeFromSubject(msg.Subject)
 	if len(event) == 0 {
@@ -232,20 +462,6 @@ func (m *Manager) StoreMessage(msg *nats.Msg) {
 		return
 	}
 
-	// Avoid duplicate messages
-	isExist, err := m.IsMessageExist(event, requestID)
-	if isExist {
-		_ = msg.Ack()
-		return
-	}
-
-	if err != nil {
-		// NOTE: Don't ack the message, because it's not a duplicate
-		_ = msg.Nak()
-		fmt.Printf("Failed to check if message exists: %v\n", err)
-		return
-	}
-
 	// Try to marshal the request
 	requestPayload, err := json.Marshal(request)
 	if err != nil {
@@ -254,29 +470,13 @@ func (m *Manager) StoreMessage(msg *nats.Msg) {
 		return
 	}
 
-	currentTime := time.Now().UTC()
-
-	msgEntry := Message{
-		Event:           event,
-		RequestID:       requestID,
-		RequestPayload:  string(requestPayload),
-		ResponsePayload: "{}",
-		Processed:       false,
-		Replied:         false,
-		RequestedAt:     requestedAt,
-		QueuedAt:        &currentTime,
-		ProcessedAt:     nil,
-	}
-
-	//	Insert in DB
-	tx := m.ReadWriteDB.Create(&msgEntry)
-	if tx.Error != nil {
+	if _, _, err := m.enqueueMessage(event, requestID, *requestedAt, requestPayload); err != nil {
 		_ = msg.Nak() // We want to retry this message
 		fmt.Printf("Failed to insert message in DB: %v\n", err)
 		return
 	}
-	err = msg.Ack()
-	if err != nil {
+	m.Metrics.recordReceived(event)
+	if err := msg.Ack(); err != nil {
 		fmt.Printf("Failed to ack message: %v\n", err)
 	}
 }
@@ -286,6 +486,7 @@ func (m *Manager) ProcessRequests() {
 	defer m.Wg.Done()
 
 	var messages []Message
+	retryBackoff := newRetryBackoff()
 
 	for {
 		select {
@@ -293,26 +494,52 @@ func (m *Manager) ProcessRequests() {
 			fmt.Print("Context is cancelled\n")
 			return
 		default:
+			// In clustered mode, only the Raft leader drives message processing -- followers
+			// leave their own local queue untouched and catch up via replicated Apply calls
+			// instead (see clusterstore.go).
+			if m.Cluster != nil && !m.Cluster.IsLeader() {
+				time.Sleep(idlePollInterval)
+				continue
+			}
+
 			//	Fetch top 100 messages from the DB
 			tx := m.ReadOnlyDB.Where("processed = ?", false).Order("queued_at asc").Limit(100).Find(&messages)
 			if tx.Error != nil {
 				fmt.Printf("failed to fetch messages from DB: %v\n", tx.Error)
-				time.Sleep(1 * time.Second)
+				if !sleepBackoff(m.Context, retryBackoff) {
+					return
+				}
 				continue
 			}
 
 			// If no result continue
 			if len(messages) == 0 {
-				time.Sleep(1 * time.Second)
+				time.Sleep(idlePollInterval)
+				continue
+			}
+
+			// In clustered mode, replicate each message through Raft instead of processing it
+			// directly -- clusterFSM.Apply runs the same enqueue/process/cleanup/notify/broadcast
+			// steps below, on every node, once the entry commits.
+			if m.Cluster != nil {
+				for i := range messages {
+					if err := m.Cluster.Propose(messages[i].Event, messages[i].RequestID, *messages[i].RequestedAt, []byte(messages[i].RequestPayload)); err != nil {
+						fmt.Printf("failed to replicate message %d: %v\n", messages[i].ID, err)
+					}
+				}
+				messages = []Message{}
+				time.Sleep(25 * time.Millisecond)
 				continue
 			}
 
 			// Create an db transaction
 			tx = m.ReadWriteDB.Begin()
 
-			//	Process each message
-			for _, msg := range messages {
-				processMessage(tx, &msg)
+			//	Process each message in place, so messages[i] reflects the outcome afterwards --
+			//	needed both to commit the right ResponsePayload and to wake up any admin API caller
+			//	waiting on that RequestID (see Manager.Completions).
+			for i := range messages {
+				processMessage(tx, &messages[i], m.Metrics)
 			}
 
 			// Cleanup unused backends and listeners
@@ -325,11 +552,18 @@ func (m *Manager) ProcessRequests() {
 			err = tx.Commit().Error
 			if err != nil {
 				fmt.Printf("Failed to commit transaction: %v\n", err)
-				time.Sleep(1 * time.Second)
+				if !sleepBackoff(m.Context, retryBackoff) {
+					return
+				}
 				continue
 			}
+			retryBackoff.Reset()
 
-			m.BroadcastChangesToProxies()
+			for i := range messages {
+				m.Completions.notify(&messages[i])
+			}
+
+			m.BroadcastChangesToProxies(affectedResourceTypes(messages)...)
 
 			//	Force GC
 			messages = []Message{}
@@ -338,6 +572,13 @@ func (m *Manager) ProcessRequests() {
 	}
 }
 
+// pendingResponsePublish tracks one in-flight js.PublishAsync call alongside the Message row it
+// came from, so SendResponsesToQueue can tell which rows actually got acked once the batch drains.
+type pendingResponsePublish struct {
+	messageID uint
+	future    nats.PubAckFuture
+}
+
 func (m *Manager) SendResponsesToQueue() {
 	m.Wg.Add(1)
 	defer m.Wg.Done()
@@ -353,6 +594,12 @@ func (m *Manager) SendResponsesToQueue() {
 	var ackedMessages []uint
 	var payloadJSONBytes []byte
 
+	batchSize := m.Config.NatsConfig.PublishBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultNatsPublishBatchSize
+	}
+	retryBackoff := newNatsRetryBackoff(&m.Config.NatsConfig)
+
 	defer func() {
 		if natsConn != nil {
 			natsConn.Close()
@@ -367,13 +614,23 @@ func (m *Manager) SendResponsesToQueue() {
 			fmt.Print("Context is cancelled\n")
 			return
 		default:
+			// In clustered mode, clusterFSM.Apply (see clusterstore.go) replays every mutation --
+			// including Message.Processed -- identically on every node, so every node would
+			// otherwise find the same processed-but-unreplied rows and republish them to NATS.
+			// Only the leader should actually send responses, same as ProcessRequests/
+			// ListenToStream.
+			if m.Cluster != nil && !m.Cluster.IsLeader() {
+				time.Sleep(idlePollInterval)
+				continue
+			}
+
 			if natsConn == nil {
 				natsConn, err = m.CreateNATSConnection()
 				if err != nil {
 					fmt.Printf("Failed to connect to NATS Server: %v\n", err)
-					natsConn.Close()
-					natsConn = nil
-					time.Sleep(1 * time.Second)
+					if !sleepBackoff(m.Context, retryBackoff) {
+						return
+					}
 					continue
 				}
 
@@ -384,39 +641,37 @@ func (m *Manager) SendResponsesToQueue() {
 					natsConn = nil
 					js = nil
 					fmt.Printf("Failed to create Jet Stream context: %v\n", err)
-					time.Sleep(1 * time.Second)
+					if !sleepBackoff(m.Context, retryBackoff) {
+						return
+					}
 					continue
 				}
+				retryBackoff.Reset()
 			}
 
-			tx := m.ReadOnlyDB.Where("processed = ? AND replied = ?", true, false).Find(&messages).Limit(200)
+			tx := m.ReadOnlyDB.Where("processed = ? AND replied = ?", true, false).Limit(batchSize).Find(&messages)
 			if tx.Error != nil {
 				fmt.Printf("Failed to find messages to send: %v\n", tx.Error)
-				return
-			}
-
-			// Prepare the responses
-			for _, msg := range messages {
-				payload := ResponsePayloadV1{
-					Event:        msg.Event,
-					MessageID:    msg.ID,
-					Success:      msg.Success,
-					Data:         json.RawMessage(msg.ResponsePayload),
-					ErrorMessage: msg.ErrorMessage,
-					ProcessedAt:  *msg.ProcessedAt,
-					QueuedAt:     *msg.QueuedAt,
+				if !sleepBackoff(m.Context, retryBackoff) {
+					return
 				}
-				payload.RequestID = msg.RequestID
-				payload.RequestedAt = *msg.RequestedAt
-
-				responsePayloads = append(responsePayloads, payload)
+				continue
 			}
 
 			if len(messages) == 0 {
+				time.Sleep(idlePollInterval)
 				continue
 			}
+			retryBackoff.Reset()
 
-			// Publish the responses in reply subjects
+			// Prepare the responses
+			for i := range messages {
+				responsePayloads = append(responsePayloads, messageToResponsePayload(&messages[i]))
+			}
+
+			// Publish the whole batch asynchronously, then drain the acks together --
+			// PublishAsyncMaxPending (set above) caps how many of these can be in flight at once.
+			pending := make([]pendingResponsePublish, 0, len(responsePayloads))
 			for _, payload := range responsePayloads {
 				payloadJSONBytes, err = json.MarshalIndent(payload, "", "  ")
 				if err != nil {
@@ -424,49 +679,252 @@ func (m *Manager) SendResponsesToQueue() {
 					continue
 				}
 
-				if _, err = js.Publish(fmt.Sprintf("%s%s", m.OutgoingStreamPrefix, payload.Event), payloadJSONBytes); err != nil {
+				future, err := js.PublishAsync(fmt.Sprintf("%s%s", m.OutgoingStreamPrefix, payload.Event), payloadJSONBytes)
+				if err != nil {
 					fmt.Printf("Failed to publish message: %v\n", err)
 					continue
 				}
+				pending = append(pending, pendingResponsePublish{messageID: payload.MessageID, future: future})
+			}
 
-				// Add to acked messages
-				ackedMessages = append(ackedMessages, payload.MessageID)
+			select {
+			case <-js.PublishAsyncComplete():
+			case <-m.Context.Done():
+				return
+			}
+
+			// Only the subset that actually got acked is marked Replied -- the rest stay
+			// unreplied and get picked up again on the next round.
+			repliedAt := time.Now().UTC()
+			for _, p := range pending {
+				select {
+				case <-p.future.Ok():
+					ackedMessages = append(ackedMessages, p.messageID)
+					for i := range messages {
+						if messages[i].ID == p.messageID {
+							m.Metrics.recordReplied(&messages[i], repliedAt)
+							break
+						}
+					}
+				case pubErr := <-p.future.Err():
+					fmt.Printf("Failed to publish message %d: %v\n", p.messageID, pubErr)
+				}
 			}
 
 			// Mark messages as replied
-			tx = m.ReadWriteDB.Model(&messages).Where("id IN (?)", ackedMessages).Updates(Message{Replied: true})
-			if tx.Error != nil {
-				fmt.Printf("Failed to mark messages as replied: %v\n", tx.Error)
+			if len(ackedMessages) > 0 {
+				tx = m.ReadWriteDB.Model(&Message{}).Where("id IN (?)", ackedMessages).Updates(Message{Replied: true})
+				if tx.Error != nil {
+					fmt.Printf("Failed to mark messages as replied: %v\n", tx.Error)
+				}
 			}
 
 			// Force GC
 			ackedMessages = []uint{}
 			messages = []Message{}
+			responsePayloads = []ResponsePayloadV1{}
 		}
 	}
 }
 
-func (m *Manager) BroadcastChangesToProxies() {
-	m.HasPendingProxyChanges.Store(true)
+// BroadcastChangesToProxies marks each given ADS resource type (see the TypeURL* constants in
+// ads.go) as having a pending change. Callers that know exactly which table(s) they wrote should
+// name only those types, e.g. a Backend health transition passes TypeURLBackends alone, so it
+// doesn't also force a resend of Listeners/IngressRules/TLSCertificates/HTTPRedirectRules to
+// every open ADS stream.
+func (m *Manager) BroadcastChangesToProxies(typeURLs ...string) {
+	for _, typeURL := range typeURLs {
+		if pending, ok := m.resourceVersions.pending[typeURL]; ok {
+			pending.Store(true)
+		}
+	}
 }
 
+// ListenForBroadcastChangesToProxies is the single consumer of resourceVersions' pending flags:
+// whenever it observes a type's flag set, it bumps that type's version so every open ADS stream
+// (see ads.go) knows it's time to push a fresh snapshot of just that type.
 func (m *Manager) ListenForBroadcastChangesToProxies() {
 	m.Wg.Add(1)
 	defer m.Wg.Done()
 
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-m.Context.Done():
 			fmt.Print("Context is cancelled\n")
 			return
-		default:
-			if m.HasPendingProxyChanges.Swap(false) {
-				continue
+		case <-ticker.C:
+			for _, typeURL := range adsResourceTypeURLs {
+				if m.resourceVersions.pending[typeURL].Swap(false) {
+					m.resourceVersions.version[typeURL].Add(1)
+				}
 			}
 		}
 	}
 }
 
+// DevCAServer exposes the dev-mode CA over the NATS control plane so operators can fetch the
+// root cert to trust in a browser, and so an external dataplane can request a minted leaf for a
+// given SNI without vessel needing to terminate TLS itself.
+func (m *Manager) DevCAServer() {
+	m.Wg.Add(1)
+	defer m.Wg.Done()
+
+	if m.DevCA == nil {
+		return
+	}
+
+	natsConn, err := m.CreateNATSConnection()
+	if err != nil {
+		fmt.Printf("DevCA: failed to connect to NATS Server: %v\n", err)
+		return
+	}
+	defer natsConn.Close()
+
+	certSubject := fmt.Sprintf("proxy.%s.dev_ca.cert", m.Config.AgentID)
+	certSub, err := natsConn.Subscribe(certSubject, func(msg *nats.Msg) {
+		_ = msg.Respond([]byte(m.DevCA.CACertPEM()))
+	})
+	if err != nil {
+		fmt.Printf("DevCA: failed to subscribe to %s: %v\n", certSubject, err)
+		return
+	}
+	defer certSub.Unsubscribe()
+
+	leafSubject := fmt.Sprintf("proxy.%s.dev_ca.leaf", m.Config.AgentID)
+	leafSub, err := natsConn.Subscribe(leafSubject, func(msg *nats.Msg) {
+		sni := strings.TrimSpace(string(msg.Data))
+		if sni == "" {
+			_ = msg.Respond(nil)
+			return
+		}
+		certPEM, keyPEM, err := m.DevCA.LeafFor(sni)
+		if err != nil {
+			_ = msg.Respond([]byte(fmt.Sprintf(`{"error":%q}`, err.Error())))
+			return
+		}
+		reply, _ := json.Marshal(map[string]string{"cert": certPEM, "key": keyPEM})
+		_ = msg.Respond(reply)
+	})
+	if err != nil {
+		fmt.Printf("DevCA: failed to subscribe to %s: %v\n", leafSubject, err)
+		return
+	}
+	defer leafSub.Unsubscribe()
+
+	<-m.Context.Done()
+}
+
+// BackendHealthServer exposes live BackendHealth rows over NATS so a control plane can visualize
+// per-host status without reading the SQLite file directly. Request data is a Backend ID; it
+// replies with a JSON array of that backend's BackendHealth rows.
+func (m *Manager) BackendHealthServer() {
+	m.Wg.Add(1)
+	defer m.Wg.Done()
+
+	natsConn, err := m.CreateNATSConnection()
+	if err != nil {
+		fmt.Printf("BackendHealth: failed to connect to NATS Server: %v\n", err)
+		return
+	}
+	defer natsConn.Close()
+
+	subject := fmt.Sprintf("proxy.%s.backend_health.get", m.Config.AgentID)
+	sub, err := natsConn.Subscribe(subject, func(msg *nats.Msg) {
+		backendID := strings.TrimSpace(string(msg.Data))
+		if backendID == "" {
+			_ = msg.Respond(nil)
+			return
+		}
+		rows, err := listBackendHealth(m.ReadOnlyDB, backendID)
+		if err != nil {
+			_ = msg.Respond([]byte(fmt.Sprintf(`{"error":%q}`, err.Error())))
+			return
+		}
+		reply, err := json.Marshal(rows)
+		if err != nil {
+			_ = msg.Respond([]byte(fmt.Sprintf(`{"error":%q}`, err.Error())))
+			return
+		}
+		_ = msg.Respond(reply)
+	})
+	if err != nil {
+		fmt.Printf("BackendHealth: failed to subscribe to %s: %v\n", subject, err)
+		return
+	}
+	defer sub.Unsubscribe()
+
+	<-m.Context.Done()
+}
+
+// ConfigSnapshotServer exposes transactional full-state config apply and rollback over NATS.
+// Unlike the per-row v1.*.upsert/delete events in handler.go, rads.<agent_id>.apply and
+// rads.<agent_id>.rollback replace or restore the agent's entire config atomically -- see
+// applyConfigSnapshot in snapshot.go.
+func (m *Manager) ConfigSnapshotServer() {
+	m.Wg.Add(1)
+	defer m.Wg.Done()
+
+	natsConn, err := m.CreateNATSConnection()
+	if err != nil {
+		fmt.Printf("ConfigSnapshot: failed to connect to NATS Server: %v\n", err)
+		return
+	}
+	defer natsConn.Close()
+
+	applySubject := fmt.Sprintf("rads.%s.apply", m.Config.AgentID)
+	applySub, err := natsConn.Subscribe(applySubject, func(msg *nats.Msg) {
+		record, err := m.applyConfigSnapshot(msg.Data)
+		_ = msg.Respond(marshalConfigApplyResult(record, err))
+	})
+	if err != nil {
+		fmt.Printf("ConfigSnapshot: failed to subscribe to %s: %v\n", applySubject, err)
+		return
+	}
+	defer applySub.Unsubscribe()
+
+	rollbackSubject := fmt.Sprintf("rads.%s.rollback", m.Config.AgentID)
+	rollbackSub, err := natsConn.Subscribe(rollbackSubject, func(msg *nats.Msg) {
+		revision, parseErr := strconv.ParseInt(strings.TrimSpace(string(msg.Data)), 10, 64)
+		if parseErr != nil {
+			_ = msg.Respond(marshalConfigApplyResult(nil, fmt.Errorf("invalid revision: %w", parseErr)))
+			return
+		}
+		record, err := m.rollbackToRevision(revision)
+		_ = msg.Respond(marshalConfigApplyResult(record, err))
+	})
+	if err != nil {
+		fmt.Printf("ConfigSnapshot: failed to subscribe to %s: %v\n", rollbackSubject, err)
+		return
+	}
+	defer rollbackSub.Unsubscribe()
+
+	<-m.Context.Done()
+}
+
+func marshalConfigApplyResult(record *ConfigRevision, applyErr error) []byte {
+	result := ConfigApplyResultV1{}
+	if record != nil {
+		result.Revision = record.Revision
+		result.SHA256 = record.SHA256
+	}
+	if applyErr != nil {
+		result.Error = applyErr.Error()
+	}
+	reply, err := json.Marshal(result)
+	if err != nil {
+		return []byte(fmt.Sprintf(`{"error":%q}`, err.Error()))
+	}
+	return reply
+}
+
 func (m *Manager) Close() {
-	return
+	if m.ReadWriteDBClose != nil {
+		m.ReadWriteDBClose()
+	}
+	if m.ReadOnlyDBClose != nil {
+		m.ReadOnlyDBClose()
+	}
 }
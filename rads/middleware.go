@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RequestIDMiddlewareConfig has no required fields today -- the header name is fixed to
+// X-Request-ID -- but it exists as a struct so the empty JSON object round-trips cleanly.
+type RequestIDMiddlewareConfig struct{}
+
+// ForwardAuthMiddlewareConfig configures a subrequest to an external auth server.
+type ForwardAuthMiddlewareConfig struct {
+	URL                    string   `json:"url"`
+	AllowedResponseHeaders []string `json:"allowed_response_headers"`
+}
+
+// HeadersMiddlewareConfig adds/removes request and response headers.
+type HeadersMiddlewareConfig struct {
+	SetRequestHeaders    map[string]string `json:"set_request_headers"`
+	RemoveRequestHeaders []string          `json:"remove_request_headers"`
+
+	SetResponseHeaders    map[string]string `json:"set_response_headers"`
+	RemoveResponseHeaders []string          `json:"remove_response_headers"`
+}
+
+// RateLimitMiddlewareConfig is a token-bucket per client IP, with optional per-CIDR overrides.
+type RateLimitMiddlewareConfig struct {
+	RatePerSecond float64                   `json:"rate_per_second"`
+	Burst         int                       `json:"burst"`
+	CIDROverrides []RateLimitCIDROverrideV1 `json:"cidr_overrides,omitempty"`
+}
+
+type RateLimitCIDROverrideV1 struct {
+	CIDR          string  `json:"cidr"`
+	RatePerSecond float64 `json:"rate_per_second"`
+	Burst         int     `json:"burst"`
+}
+
+// validateMiddlewareConfig checks that config is well-formed for middlewareType, returning a
+// descriptive error rather than letting a broken middleware get persisted.
+func validateMiddlewareConfig(middlewareType MiddlewareType, config json.RawMessage) error {
+	switch middlewareType {
+	case MiddlewareRequestID:
+		var cfg RequestIDMiddlewareConfig
+		return json.Unmarshal(config, &cfg)
+
+	case MiddlewareForwardAuth:
+		var cfg ForwardAuthMiddlewareConfig
+		if err := json.Unmarshal(config, &cfg); err != nil {
+			return err
+		}
+		if cfg.URL == "" {
+			return fmt.Errorf("forward_auth middleware requires url")
+		}
+		return nil
+
+	case MiddlewareHeaders:
+		var cfg HeadersMiddlewareConfig
+		return json.Unmarshal(config, &cfg)
+
+	case MiddlewareRateLimit:
+		var cfg RateLimitMiddlewareConfig
+		if err := json.Unmarshal(config, &cfg); err != nil {
+			return err
+		}
+		if cfg.RatePerSecond <= 0 {
+			return fmt.Errorf("rate_limit middleware requires rate_per_second > 0")
+		}
+		if cfg.Burst <= 0 {
+			return fmt.Errorf("rate_limit middleware requires burst > 0")
+		}
+		for _, override := range cfg.CIDROverrides {
+			if !IsValidCIDR(override.CIDR) {
+				return fmt.Errorf("invalid cidr in rate_limit override: %s", override.CIDR)
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown middleware type: %s", middlewareType)
+	}
+}
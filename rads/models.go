@@ -26,6 +26,37 @@ type TLSCertificate struct {
 	Cert       string    `gorm:"column:cert" json:"cert"`
 	Key        string    `gorm:"column:key" json:"-"`
 	ExpiresAt  time.Time `gorm:"column:expires_at" json:"expires_at"`
+
+	// Managed marks this row as owned by the ACME renewer (see acme.go): only Managed rows are
+	// picked up by renewDueCertificates, so a manually uploaded certificate never gets silently
+	// replaced by an ACME-issued one and vice versa.
+	Managed bool `gorm:"column:managed;index;default:false" json:"managed"`
+}
+
+// ACMEAccount holds a registered ACME account (Let's Encrypt or any RFC 8555 CA) used by the
+// renewer to issue and renew TLSCertificate rows on behalf of ingress domains.
+type ACMEAccount struct {
+	ID           string `gorm:"primaryKey" json:"id"`
+	Email        string `gorm:"column:email;uniqueIndex;not null" json:"email"`
+	DirectoryURL string `gorm:"column:directory_url;not null" json:"directory_url"`
+	KeyPEM       string `gorm:"column:key_pem" json:"-"`
+	EABKeyID     string `gorm:"column:eab_key_id" json:"-"`
+	EABHMACKey   string `gorm:"column:eab_hmac_key" json:"-"`
+}
+
+// ACMEChallenge selects, per domain, which ACME challenge type and DNS-01 provider to use when
+// the renewer issues or renews a certificate for that domain.
+type ACMEChallenge struct {
+	ID     string `gorm:"primaryKey" json:"id"`
+	Domain string `gorm:"column:domain;uniqueIndex;not null" json:"domain"`
+
+	// ChallengeType is one of "http-01" or "dns-01".
+	ChallengeType string `gorm:"column:challenge_type;default:http-01" json:"challenge_type"`
+
+	// DNSProvider is one of "cloudflare", "route53", "rfc2136" and only applies to dns-01.
+	DNSProvider string `gorm:"column:dns_provider" json:"dns_provider,omitempty"`
+	// DNSProviderConfig holds provider credentials/config as a JSON blob.
+	DNSProviderConfig string `gorm:"column:dns_provider_config" json:"-"`
 }
 
 type Listener struct {
@@ -41,6 +72,12 @@ type Backend struct {
 	ResolverType BackendResolverType `gorm:"column:resolver_type;index;default:static;not null" json:"resolver_type"`
 	DNSResolver  string              `gorm:"column:dns_resolver" json:"dns_resolver,omitempty"` // e.g., "8.8.8.8:53"
 
+	// DNS-over-TLS / DNS-over-HTTPS options, only meaningful for ResolverType dot/doh. See
+	// DOT_RESOLVER/DOH_RESOLVER in types.go for what each controls.
+	DNSServerName   string     `gorm:"column:dns_server_name" json:"dns_server_name,omitempty"`
+	DNSBootstrapIPs StringList `gorm:"column:dns_bootstrap_ips;type:text" json:"dns_bootstrap_ips,omitempty"`
+	DNSCABundle     string     `gorm:"column:dns_ca_bundle" json:"dns_ca_bundle,omitempty"`
+
 	// Hosts as JSON string (e.g., '["10.0.0.1","10.0.0.2"]'
 	Hosts StringList `gorm:"column:hosts;type:text;not null" json:"hosts"`
 	Port  int        `gorm:"column:port;index;not null" json:"port"`
@@ -48,6 +85,83 @@ type Backend struct {
 	// Upstream TLS
 	IsTLS     bool   `gorm:"column:is_tls;index;default:false" json:"is_tls"`
 	SNIDomain string `gorm:"column:sni_domain" json:"sni_domain,omitempty"`
+
+	// LBPolicy controls how a proxy spreads requests across Hosts at selection time.
+	LBPolicy LBPolicy `gorm:"column:lb_policy;default:round_robin;not null" json:"lb_policy"`
+
+	// Active health check config, applied by the supervisor in health.go. HealthCheckPath empty
+	// means "TCP connect only"; set it to also require a successful HTTP GET (respecting IsTLS /
+	// SNIDomain) returning ExpectedStatus.
+	HealthCheckPath     string `gorm:"column:health_check_path" json:"health_check_path,omitempty"`
+	HealthCheckInterval int    `gorm:"column:health_check_interval;default:10;not null" json:"health_check_interval"` // seconds
+	HealthyThreshold    int    `gorm:"column:healthy_threshold;default:2;not null" json:"healthy_threshold"`
+	UnhealthyThreshold  int    `gorm:"column:unhealthy_threshold;default:3;not null" json:"unhealthy_threshold"`
+	ExpectedStatus      int    `gorm:"column:expected_status;default:200;not null" json:"expected_status"`
+
+	// FailOpen keeps every host selectable when all of them are marked unhealthy, instead of
+	// leaving the backend with no candidates at all.
+	FailOpen bool `gorm:"column:fail_open;default:true;not null" json:"fail_open"`
+}
+
+// BackendHealth tracks the live up/down state of a single host within a Backend. Rows are
+// maintained transactionally by the health check supervisor (see health.go) and consulted by
+// SelectBackendHosts at selection time; a host with no row yet hasn't been probed and is treated
+// as healthy.
+type BackendHealth struct {
+	ID        string `gorm:"primaryKey" json:"id"` // backend_id + "|" + host
+	BackendID string `gorm:"column:backend_id;index;not null" json:"backend_id"`
+	Host      string `gorm:"column:host;index;not null" json:"host"`
+
+	Healthy              bool   `gorm:"column:healthy;default:true;not null" json:"healthy"`
+	ConsecutiveSuccesses int    `gorm:"column:consecutive_successes;default:0;not null" json:"consecutive_successes"`
+	ConsecutiveFailures  int    `gorm:"column:consecutive_failures;default:0;not null" json:"consecutive_failures"`
+	LastError            string `gorm:"column:last_error" json:"last_error,omitempty"`
+
+	LastCheckedAt time.Time `gorm:"column:last_checked_at" json:"last_checked_at"`
+}
+
+// HealthCheck configures an active probe used to decide whether an IngressRuleBackend pool is
+// healthy, driving primary -> backup failover between pools (see poolhealth.go). This is separate
+// from the per-host checks Backend/BackendHealth already carry (see health.go): that mechanism
+// narrows a single pool's own Hosts down to the live ones, while a HealthCheck here decides
+// whether the whole pool should be in rotation at all.
+type HealthCheck struct {
+	ID string `gorm:"primaryKey" json:"id"`
+
+	// Path empty (or TCPOnly set) means TCP-connect only; otherwise a GET to Path must return one
+	// of ExpectedStatusCodes (any 2xx when that list is empty).
+	Path    string `gorm:"column:path" json:"path,omitempty"`
+	TCPOnly bool   `gorm:"column:tcp_only;default:false;not null" json:"tcp_only"`
+
+	IntervalSeconds     int        `gorm:"column:interval_seconds;default:10;not null" json:"interval_seconds"`
+	TimeoutSeconds      int        `gorm:"column:timeout_seconds;default:5;not null" json:"timeout_seconds"`
+	HealthyThreshold    int        `gorm:"column:healthy_threshold;default:2;not null" json:"healthy_threshold"`
+	UnhealthyThreshold  int        `gorm:"column:unhealthy_threshold;default:3;not null" json:"unhealthy_threshold"`
+	ExpectedStatusCodes StringList `gorm:"column:expected_status_codes;type:text" json:"expected_status_codes,omitempty"`
+}
+
+// IngressRuleBackend attaches one weighted (and optionally backup) Backend pool to an
+// IngressRule. It replaces IngressRule's old single BackendID column so a route can fan out
+// across several pools with weighted round-robin selection (see SelectWeightedIngressRuleBackend)
+// and automatic failover to IsBackup pools once every primary pool is unhealthy (see
+// SelectIngressRuleBackends). HealthCheckID is optional: leave it empty to rely solely on
+// health.go's per-host BackendHealth checks for this pool, with no pool-level failover signal.
+type IngressRuleBackend struct {
+	ID            string `gorm:"primaryKey" json:"id"`
+	IngressRuleID string `gorm:"column:ingress_rule_id;index;not null" json:"ingress_rule_id"`
+
+	BackendID string  `gorm:"column:backend_id;index;not null" json:"backend_id"`
+	Backend   Backend `gorm:"foreignKey:BackendID;references:ID" json:"backend"`
+
+	// Weight controls this pool's share of traffic among the candidate pools
+	// SelectIngressRuleBackends returns; defaults to 1 when left zero.
+	Weight int `gorm:"column:weight;default:1;not null" json:"weight"`
+	// IsBackup marks this pool as only receiving traffic once every non-backup pool on the same
+	// IngressRule is unhealthy.
+	IsBackup bool `gorm:"column:is_backup;default:false;not null" json:"is_backup"`
+
+	HealthCheckID string       `gorm:"column:health_check_id" json:"health_check_id,omitempty"`
+	HealthCheck   *HealthCheck `gorm:"foreignKey:HealthCheckID;references:ID" json:"health_check,omitempty"`
 }
 
 type IngressRule struct {
@@ -56,9 +170,16 @@ type IngressRule struct {
 
 	// Relation
 	ListenerID string   `gorm:"column:listener_id;index;not null" json:"listener_id"`
-	BackendID  string   `gorm:"column:backend_id;index;not null" json:"backend_id"`
 	Listener   Listener `gorm:"foreignKey:ListenerID;references:ID" json:"listener"`
-	Backend    Backend  `gorm:"foreignKey:BackendID;references:ID" json:"backend"`
+
+	// Backends is the set of backend pools this rule fans out to; see IngressRuleBackend for
+	// weight/backup/health-check semantics.
+	Backends []IngressRuleBackend `gorm:"foreignKey:IngressRuleID;references:ID" json:"backends"`
+
+	// Middlewares is this rule's ordered middleware chain; see IngressRuleMiddleware for the
+	// join row that carries Order, and setIngressRuleMiddlewares for how it's replaced wholesale
+	// on upsert.
+	Middlewares []IngressRuleMiddleware `gorm:"foreignKey:IngressRuleID;references:ID" json:"middlewares"`
 
 	// Routing
 	Domain      string `gorm:"column:domain;index" json:"domain,omitempty"`       // Empty means match all
@@ -69,6 +190,33 @@ type IngressRule struct {
 	DeniedCIDRs  StringList `gorm:"column:denied_cidrs;type:text" json:"denied_cidrs,omitempty"`
 }
 
+// MiddlewareType enumerates the kinds of middleware that can sit in an IngressRule's chain.
+type MiddlewareType string
+
+const (
+	MiddlewareRequestID   MiddlewareType = "request_id"
+	MiddlewareForwardAuth MiddlewareType = "forward_auth"
+	MiddlewareHeaders     MiddlewareType = "headers"
+	MiddlewareRateLimit   MiddlewareType = "rate_limit"
+)
+
+// MiddlewareChain is a single configured middleware instance, reusable across IngressRules via
+// IngressRuleMiddleware. Config is opaque JSON whose shape depends on Type (see
+// validateMiddlewareConfig).
+type MiddlewareChain struct {
+	ID     string         `gorm:"primaryKey" json:"id"`
+	Type   MiddlewareType `gorm:"column:type;index;not null" json:"type"`
+	Config string         `gorm:"column:config;not null" json:"config"`
+}
+
+// IngressRuleMiddleware orders a MiddlewareChain within a given IngressRule's chain.
+type IngressRuleMiddleware struct {
+	IngressRuleID string          `gorm:"column:ingress_rule_id;primaryKey" json:"ingress_rule_id"`
+	MiddlewareID  string          `gorm:"column:middleware_id;primaryKey" json:"middleware_id"`
+	Order         int             `gorm:"column:order;not null" json:"order"`
+	Middleware    MiddlewareChain `gorm:"foreignKey:MiddlewareID;references:ID" json:"middleware"`
+}
+
 type HTTPRedirectRule struct {
 	ID         string   `gorm:"primaryKey" json:"id"`
 	Priority   int      `gorm:"column:priority;index;default:0" json:"priority"`
@@ -87,4 +235,26 @@ type HTTPRedirectRule struct {
 	HostRedirect   string `gorm:"column:host_redirect" json:"host_redirect,omitempty"`
 	PathRedirect   string `gorm:"column:path_redirect" json:"path_redirect,omitempty"`
 	StatusCode     int    `gorm:"column:status_code;default:301" json:"status_code"` // 301, 302, 307, 308
+
+	// PathRegex + PathReplacement rewrite the matched path with Go's regexp.Expand syntax
+	// ($1, ${name}) instead of the plain PathRedirect substitution, when PathRegex is set. See
+	// compileCachedPathRegex and validatePathReplacement in redirect.go.
+	PathRegex       string `gorm:"column:path_regex" json:"path_regex,omitempty"`
+	PathReplacement string `gorm:"column:path_replacement" json:"path_replacement,omitempty"`
+
+	// PreserveQuery appends the original request's query string to the redirect Location.
+	PreserveQuery bool `gorm:"column:preserve_query;default:false" json:"preserve_query"`
+
+	// ResponseHeaders are merged into the redirect response alongside Location.
+	ResponseHeaders StringMap `gorm:"column:response_headers;type:text" json:"response_headers,omitempty"`
+}
+
+// ConfigRevision persists one accepted ConfigSnapshotV1 (see snapshot.go) as canonical JSON, so
+// rads.<agent_id>.rollback can replay it later. Revision increases monotonically; it is not tied
+// to wall-clock time so replays always produce a new, later revision.
+type ConfigRevision struct {
+	Revision     int64     `gorm:"primaryKey;autoIncrement:false" json:"revision"`
+	SnapshotJSON string    `gorm:"column:snapshot_json;not null" json:"snapshot_json"`
+	SHA256       string    `gorm:"column:sha256;index;not null" json:"sha256"`
+	CreatedAt    time.Time `gorm:"column:created_at;not null" json:"created_at"`
 }
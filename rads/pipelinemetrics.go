@@ -0,0 +1,215 @@
+package main
+
+import (
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+// maxRecentFailedMessages bounds the in-memory ring newPipelineMetrics exposes via the
+// "vessel_failed_messages" expvar, so an operator can see why recent messages failed without a
+// DB query.
+const maxRecentFailedMessages = 50
+
+// FailedMessageSnapshot is one entry in the "vessel_failed_messages" expvar.
+type FailedMessageSnapshot struct {
+	Event        string    `json:"event"`
+	RequestID    string    `json:"request_id"`
+	ErrorMessage string    `json:"error_message"`
+	ProcessedAt  time.Time `json:"processed_at"`
+}
+
+// PipelineMetrics instruments the four message-pipeline goroutines (ListenToStream,
+// StoreRequestsAndAcknowledge, ProcessRequests, SendResponsesToQueue) against one Prometheus
+// registry, mounted at /metrics by AdminAPIServer (see adminapi.go). Manager always creates one,
+// since the pipeline runs whether or not the admin API is enabled.
+type PipelineMetrics struct {
+	Registry *prometheus.Registry
+
+	messagesReceivedTotal  *prometheus.CounterVec
+	messagesProcessedTotal *prometheus.CounterVec
+	messagesFailedTotal    *prometheus.CounterVec
+	messagesRepliedTotal   *prometheus.CounterVec
+
+	queuedToProcessedSeconds  *prometheus.HistogramVec
+	processedToRepliedSeconds *prometheus.HistogramVec
+
+	// listenerActiveConnections/backendInFlightRequests: rads is a control plane -- Envoy, not
+	// this process, terminates the actual traffic for the Listener/Backend rows it manages, so
+	// there's no general signal here for "connections on listener X" / "requests to backend Y".
+	// These track the closest real analogues this process has: in-flight requests against HTTP
+	// servers rads itself runs (currently the ACME HTTP-01 challenge responder, see acme.go) and
+	// in-flight health check probes against a backend (see health.go, poolhealth.go).
+	listenerActiveConnections *prometheus.GaugeVec
+	backendInFlightRequests   *prometheus.GaugeVec
+
+	failedMu     sync.Mutex
+	recentFailed []FailedMessageSnapshot
+}
+
+func newPipelineMetrics(db *gorm.DB) *PipelineMetrics {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector())
+	registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	pm := &PipelineMetrics{
+		Registry: registry,
+		messagesReceivedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vessel_messages_received_total",
+			Help: "Messages received into the queue (from NATS or the admin API), by event.",
+		}, []string{"event"}),
+		messagesProcessedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vessel_messages_processed_total",
+			Help: "Messages run through processMessage, by event.",
+		}, []string{"event"}),
+		messagesFailedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vessel_messages_failed_total",
+			Help: "Messages processed but not Success, by event.",
+		}, []string{"event"}),
+		messagesRepliedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vessel_messages_replied_total",
+			Help: "Messages acked back onto the outgoing NATS stream, by event.",
+		}, []string{"event"}),
+		queuedToProcessedSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "vessel_message_queued_to_processed_seconds",
+			Help: "Seconds between a message being queued (QueuedAt) and processMessage finishing (ProcessedAt), by event.",
+		}, []string{"event"}),
+		processedToRepliedSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "vessel_message_processed_to_replied_seconds",
+			Help: "Seconds between processMessage finishing (ProcessedAt) and the reply being acked onto the outgoing NATS stream, by event.",
+		}, []string{"event"}),
+		listenerActiveConnections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "vessel_listener_active_connections",
+			Help: "In-flight HTTP connections on an HTTP server rads itself runs, by listen address.",
+		}, []string{"listener"}),
+		backendInFlightRequests: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "vessel_backend_inflight_requests",
+			Help: "In-flight health check probes against a backend, by backend ID.",
+		}, []string{"backend"}),
+	}
+	registry.MustRegister(
+		pm.messagesReceivedTotal,
+		pm.messagesProcessedTotal,
+		pm.messagesFailedTotal,
+		pm.messagesRepliedTotal,
+		pm.queuedToProcessedSeconds,
+		pm.processedToRepliedSeconds,
+		pm.listenerActiveConnections,
+		pm.backendInFlightRequests,
+		newTLSCertificateExpiryCollector(db),
+	)
+
+	expvar.Publish("vessel_failed_messages", expvar.Func(func() interface{} {
+		return pm.recentFailedMessages()
+	}))
+
+	return pm
+}
+
+// recordReceived is called from StoreMessage (StoreRequestsAndAcknowledge) once a message off
+// NATS is durably enqueued, and from handleAdminAPIEvent (adminapi.go) once an admin API event is.
+func (pm *PipelineMetrics) recordReceived(event string) {
+	pm.messagesReceivedTotal.WithLabelValues(event).Inc()
+}
+
+// recordProcessed is called from processMessage (ProcessRequests, and clusterFSM.Apply in
+// clustered mode) once msg has its final Success/ErrorMessage/ProcessedAt set.
+func (pm *PipelineMetrics) recordProcessed(msg *Message) {
+	pm.messagesProcessedTotal.WithLabelValues(msg.Event).Inc()
+	if msg.QueuedAt != nil && msg.ProcessedAt != nil {
+		pm.queuedToProcessedSeconds.WithLabelValues(msg.Event).Observe(msg.ProcessedAt.Sub(*msg.QueuedAt).Seconds())
+	}
+	if !msg.Success {
+		pm.messagesFailedTotal.WithLabelValues(msg.Event).Inc()
+		pm.recordFailedMessage(msg)
+	}
+}
+
+// recordReplied is called from SendResponsesToQueue once a batch of replies is confirmed acked
+// by NATS.
+func (pm *PipelineMetrics) recordReplied(msg *Message, repliedAt time.Time) {
+	pm.messagesRepliedTotal.WithLabelValues(msg.Event).Inc()
+	if msg.ProcessedAt != nil {
+		pm.processedToRepliedSeconds.WithLabelValues(msg.Event).Observe(repliedAt.Sub(*msg.ProcessedAt).Seconds())
+	}
+}
+
+func (pm *PipelineMetrics) recordFailedMessage(msg *Message) {
+	snap := FailedMessageSnapshot{
+		Event:        msg.Event,
+		RequestID:    msg.RequestID,
+		ErrorMessage: msg.ErrorMessage,
+	}
+	if msg.ProcessedAt != nil {
+		snap.ProcessedAt = *msg.ProcessedAt
+	}
+
+	pm.failedMu.Lock()
+	defer pm.failedMu.Unlock()
+	pm.recentFailed = append(pm.recentFailed, snap)
+	if len(pm.recentFailed) > maxRecentFailedMessages {
+		pm.recentFailed = pm.recentFailed[len(pm.recentFailed)-maxRecentFailedMessages:]
+	}
+}
+
+func (pm *PipelineMetrics) recentFailedMessages() []FailedMessageSnapshot {
+	pm.failedMu.Lock()
+	defer pm.failedMu.Unlock()
+	out := make([]FailedMessageSnapshot, len(pm.recentFailed))
+	copy(out, pm.recentFailed)
+	return out
+}
+
+func (pm *PipelineMetrics) incListenerConnections(listener string) {
+	pm.listenerActiveConnections.WithLabelValues(listener).Inc()
+}
+
+func (pm *PipelineMetrics) decListenerConnections(listener string) {
+	pm.listenerActiveConnections.WithLabelValues(listener).Dec()
+}
+
+func (pm *PipelineMetrics) incBackendInFlight(backendID string) {
+	pm.backendInFlightRequests.WithLabelValues(backendID).Inc()
+}
+
+func (pm *PipelineMetrics) decBackendInFlight(backendID string) {
+	pm.backendInFlightRequests.WithLabelValues(backendID).Dec()
+}
+
+// tlsCertificateExpiryCollector implements prometheus.Collector, computing
+// vessel_tls_certificate_expiry_seconds fresh from the DB on every scrape instead of keeping a
+// gauge updated out of band -- TLSCertificate rows change rarely enough that a per-scrape query
+// is cheap, and this way the value is never stale.
+type tlsCertificateExpiryCollector struct {
+	db   *gorm.DB
+	desc *prometheus.Desc
+}
+
+func newTLSCertificateExpiryCollector(db *gorm.DB) *tlsCertificateExpiryCollector {
+	return &tlsCertificateExpiryCollector{
+		db: db,
+		desc: prometheus.NewDesc(
+			"vessel_tls_certificate_expiry_seconds",
+			"Unix timestamp (seconds) at which a TLSCertificate expires, by domain.",
+			[]string{"domain"}, nil,
+		),
+	}
+}
+
+func (c *tlsCertificateExpiryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *tlsCertificateExpiryCollector) Collect(ch chan<- prometheus.Metric) {
+	certs, err := listTLSCertificates(c.db)
+	if err != nil {
+		return
+	}
+	for _, cert := range certs {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(cert.ExpiresAt.Unix()), cert.Domain)
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// poolHealthTickInterval mirrors healthCheckTickInterval's role for per-host checks: short
+	// enough that every HealthCheck.IntervalSeconds is honored fairly closely without a
+	// per-pool timer.
+	poolHealthTickInterval = 2 * time.Second
+	poolHealthTimeout      = 5 * time.Second
+)
+
+// poolHealthState is one IngressRuleBackend's live probe state, kept only in memory -- a restart
+// resets every pool back to healthy until its first probe completes.
+type poolHealthState struct {
+	healthy            bool
+	consecutiveSuccess int
+	consecutiveFailure int
+	lastCheckedAt      time.Time
+}
+
+// PoolHealthTracker holds the in-memory health map PoolHealthChecker updates and
+// SelectIngressRuleBackends reads from, keyed by IngressRuleBackend.ID. It's distinct from the
+// DB-persisted, per-host BackendHealth rows in health.go: this tracks a whole pool's healthiness
+// for primary/backup failover, not individual host liveness within one pool.
+type PoolHealthTracker struct {
+	mu     sync.Mutex
+	states map[string]*poolHealthState
+}
+
+func newPoolHealthTracker() *PoolHealthTracker {
+	return &PoolHealthTracker{states: make(map[string]*poolHealthState)}
+}
+
+// Healthy reports whether linkID is currently considered healthy. A pool with no HealthCheck
+// configured, or one PoolHealthChecker hasn't probed yet, is treated as healthy.
+func (t *PoolHealthTracker) Healthy(linkID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.states[linkID]
+	if !ok {
+		return true
+	}
+	return state.healthy
+}
+
+// due reports whether linkID is due for another probe given intervalSeconds.
+func (t *PoolHealthTracker) due(linkID string, intervalSeconds int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.states[linkID]
+	if !ok {
+		return true
+	}
+	return time.Since(state.lastCheckedAt) >= time.Duration(intervalSeconds)*time.Second
+}
+
+// record applies the outcome of a probe, flipping healthy once the matching threshold is crossed,
+// and reports whether that flip happened.
+func (t *PoolHealthTracker) record(linkID string, healthyThreshold int, unhealthyThreshold int, checkErr error) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.states[linkID]
+	if !ok {
+		state = &poolHealthState{healthy: true}
+		t.states[linkID] = state
+	}
+	state.lastCheckedAt = time.Now()
+
+	transitioned := false
+	if checkErr == nil {
+		state.consecutiveSuccess++
+		state.consecutiveFailure = 0
+		if !state.healthy && state.consecutiveSuccess >= healthyThreshold {
+			state.healthy = true
+			transitioned = true
+		}
+	} else {
+		state.consecutiveFailure++
+		state.consecutiveSuccess = 0
+		if state.healthy && state.consecutiveFailure >= unhealthyThreshold {
+			state.healthy = false
+			transitioned = true
+		}
+	}
+	return transitioned
+}
+
+// PoolHealthChecker runs a supervisor that probes every IngressRuleBackend with a HealthCheck
+// attached, on its own configured cadence, and updates m.PoolHealth accordingly.
+func (m *Manager) PoolHealthChecker() {
+	m.Wg.Add(1)
+	defer m.Wg.Done()
+
+	ticker := time.NewTicker(poolHealthTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.Context.Done():
+			return
+		case <-ticker.C:
+			m.runDuePoolHealthChecks()
+		}
+	}
+}
+
+func (m *Manager) runDuePoolHealthChecks() {
+	links, err := listIngressRuleBackends(m.ReadOnlyDB)
+	if err != nil {
+		fmt.Printf("PoolHealthCheck: failed to list ingress rule backends: %v\n", err)
+		return
+	}
+
+	for _, link := range links {
+		if link.HealthCheck == nil {
+			continue
+		}
+		if !m.PoolHealth.due(link.ID, link.HealthCheck.IntervalSeconds) {
+			continue
+		}
+
+		m.Metrics.incBackendInFlight(link.Backend.ID)
+		checkErr := probePoolBackend(&link.Backend, link.HealthCheck)
+		m.Metrics.decBackendInFlight(link.Backend.ID)
+		if m.PoolHealth.record(link.ID, link.HealthCheck.HealthyThreshold, link.HealthCheck.UnhealthyThreshold, checkErr) {
+			m.BroadcastChangesToProxies(TypeURLBackends)
+		}
+	}
+}
+
+// probePoolBackend probes every host of backend and succeeds only if all of them do -- a pool
+// counts as healthy for failover purposes only while it can serve traffic from any of its hosts.
+func probePoolBackend(backend *Backend, check *HealthCheck) error {
+	if len(backend.Hosts) == 0 {
+		return fmt.Errorf("backend %s has no hosts", backend.ID)
+	}
+	for _, host := range backend.Hosts {
+		if err := probePoolHost(backend, host, check); err != nil {
+			return fmt.Errorf("%s: %w", host, err)
+		}
+	}
+	return nil
+}
+
+// probePoolHost checks a single host. With check.TCPOnly set, or Path left empty, it's a plain
+// TCP connect; otherwise a TCP connect is followed by an HTTP GET for Path (HTTPS with
+// backend.SNIDomain when backend.IsTLS), requiring an expectedStatusCodeAllowed status back.
+func probePoolHost(backend *Backend, host string, check *HealthCheck) error {
+	addr := net.JoinHostPort(host, fmt.Sprintf("%d", backend.Port))
+
+	conn, err := net.DialTimeout("tcp", addr, poolHealthTimeout)
+	if err != nil {
+		return fmt.Errorf("tcp connect failed: %w", err)
+	}
+	_ = conn.Close()
+
+	if check.TCPOnly || check.Path == "" {
+		return nil
+	}
+
+	scheme := "http"
+	client := &http.Client{Timeout: poolHealthTimeout}
+	if backend.IsTLS {
+		scheme = "https"
+		tlsConfig := &tls.Config{}
+		if backend.SNIDomain != "" {
+			tlsConfig.ServerName = backend.SNIDomain
+		}
+		client.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+	}
+
+	url := fmt.Sprintf("%s://%s:%d%s", scheme, host, backend.Port, check.Path)
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("http check failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if !expectedStatusCodeAllowed(resp.StatusCode, check.ExpectedStatusCodes) {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// expectedStatusCodeAllowed reports whether code satisfies expected. An empty expected list
+// matches any 2xx response.
+func expectedStatusCodeAllowed(code int, expected []string) bool {
+	if len(expected) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, want := range expected {
+		if want == fmt.Sprintf("%d", code) {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectIngressRuleBackends narrows links down to the ones currently healthy per tracker,
+// preferring non-backup pools: if any non-backup pool is healthy, only those are returned,
+// falling back to the healthy backup pools otherwise, and to every pool if none are healthy.
+func SelectIngressRuleBackends(tracker *PoolHealthTracker, links []IngressRuleBackend) []IngressRuleBackend {
+	var primaryHealthy, backupHealthy []IngressRuleBackend
+	for _, link := range links {
+		if !tracker.Healthy(link.ID) {
+			continue
+		}
+		if link.IsBackup {
+			backupHealthy = append(backupHealthy, link)
+		} else {
+			primaryHealthy = append(primaryHealthy, link)
+		}
+	}
+
+	if len(primaryHealthy) > 0 {
+		return primaryHealthy
+	}
+	if len(backupHealthy) > 0 {
+		return backupHealthy
+	}
+	return links
+}
+
+// SelectWeightedIngressRuleBackend picks one backend pool from candidates, weighted by Weight,
+// using r in [0, 1) to make the pick -- callers typically pass rand.Float64().
+func SelectWeightedIngressRuleBackend(candidates []IngressRuleBackend, r float64) (*IngressRuleBackend, error) {
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no candidate backend pools available")
+	}
+
+	total := 0
+	for _, link := range candidates {
+		weight := link.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		total += weight
+	}
+
+	target := int(r * float64(total))
+	cumulative := 0
+	for i := range candidates {
+		weight := candidates[i].Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		cumulative += weight
+		if target < cumulative {
+			return &candidates[i], nil
+		}
+	}
+	return &candidates[len(candidates)-1], nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// pathRegexCache holds compiled PathRegex patterns keyed by their source string, so repeated
+// upserts/redirects for the same rule don't pay regexp.Compile on every request.
+var pathRegexCache sync.Map // map[string]*regexp.Regexp
+
+// compileCachedPathRegex compiles pattern, caching the result for subsequent callers.
+func compileCachedPathRegex(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := pathRegexCache.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	pathRegexCache.Store(pattern, re)
+	return re, nil
+}
+
+// validatePathReplacement checks that replacement only references capture groups that re
+// actually has (by number, via $1-style references, or by name, via ${name}-style references),
+// so a typo'd reference fails at upsert time instead of silently expanding to an empty string.
+func validatePathReplacement(re *regexp.Regexp, replacement string) error {
+	numGroups := re.NumSubexp()
+	names := re.SubexpNames()
+
+	for i := 0; i < len(replacement); i++ {
+		if replacement[i] != '$' {
+			continue
+		}
+
+		rest := replacement[i+1:]
+		if len(rest) == 0 {
+			continue
+		}
+
+		if rest[0] == '{' {
+			end := -1
+			for j := 1; j < len(rest); j++ {
+				if rest[j] == '}' {
+					end = j
+					break
+				}
+			}
+			if end == -1 {
+				return fmt.Errorf("path_replacement has an unterminated ${...} reference")
+			}
+			ref := rest[1:end]
+			i += end + 1
+
+			if isAllDigits(ref) {
+				if !hasGroupNumber(ref, numGroups) {
+					return fmt.Errorf("path_replacement references group ${%s}, but path_regex only has %d capture group(s)", ref, numGroups)
+				}
+				continue
+			}
+			if !hasGroupName(names, ref) {
+				return fmt.Errorf("path_replacement references named group ${%s}, which is not defined in path_regex", ref)
+			}
+			continue
+		}
+
+		if rest[0] == '$' {
+			i++ // "$$" is a literal "$"
+			continue
+		}
+
+		// Like regexp.Expand, an unbraced reference takes the longest run of letters, digits and
+		// underscores, so "$1x" names group "1x", not group 1 followed by "x".
+		j := 0
+		for j < len(rest) && (rest[j] == '_' || rest[j] >= '0' && rest[j] <= '9' || rest[j] >= 'a' && rest[j] <= 'z' || rest[j] >= 'A' && rest[j] <= 'Z') {
+			j++
+		}
+		if j == 0 {
+			continue // lone "$" or "$" followed by a non-name, non-brace char: not a reference
+		}
+		ref := rest[:j]
+		i += j
+		if isAllDigits(ref) {
+			if !hasGroupNumber(ref, numGroups) {
+				return fmt.Errorf("path_replacement references group $%s, but path_regex only has %d capture group(s)", ref, numGroups)
+			}
+			continue
+		}
+		if !hasGroupName(names, ref) {
+			return fmt.Errorf("path_replacement references named group $%s, which is not defined in path_regex", ref)
+		}
+	}
+
+	return nil
+}
+
+// isAllDigits reports whether ref is a non-empty run of ASCII digits. regexp.Expand treats any
+// ${name} whose name isn't entirely digits as a named-group lookup, so this -- not fmt.Sscanf,
+// which happily stops at the first non-digit and reports success -- is what decides whether a
+// ${...} reference is numeric.
+func isAllDigits(ref string) bool {
+	if ref == "" {
+		return false
+	}
+	for i := 0; i < len(ref); i++ {
+		if ref[i] < '0' || ref[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func hasGroupNumber(ref string, numGroups int) bool {
+	if !isAllDigits(ref) {
+		return false
+	}
+	n, err := strconv.Atoi(ref)
+	if err != nil {
+		return false
+	}
+	return n >= 0 && n <= numGroups
+}
+
+func hasGroupName(names []string, ref string) bool {
+	for _, name := range names {
+		if name == ref {
+			return true
+		}
+	}
+	return false
+}
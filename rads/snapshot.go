@@ -0,0 +1,135 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ConfigSnapshotV1 is the full desired-state document accepted on the rads.<agent_id>.apply
+// subject. It reuses the same per-row upsert payloads as the v1.*.upsert NATS events (see
+// types.go and handler.go) so validation and upsert logic isn't duplicated -- the difference is
+// that applying a snapshot also deletes whatever existing rows aren't named in it, and does the
+// whole thing in one transaction instead of row by row.
+type ConfigSnapshotV1 struct {
+	IngressRules      []IngressRuleUpsertV1      `json:"ingress_rules"`
+	HTTPRedirectRules []HTTPRedirectRuleUpsertV1 `json:"http_redirect_rules"`
+	TLSCertificates   []TLSCertificateUpsertV1   `json:"tls_certificates"`
+}
+
+// ConfigApplyResultV1 is the reply payload for both rads.<agent_id>.apply and
+// rads.<agent_id>.rollback.
+type ConfigApplyResultV1 struct {
+	Revision int64  `json:"revision"`
+	SHA256   string `json:"sha256"`
+	Error    string `json:"error,omitempty"`
+}
+
+// applyConfigSnapshot parses rawJSON as a ConfigSnapshotV1 and applies it atomically: every row
+// it describes is upserted, everything else is deleted, cleanupUnusedBackendsAndListeners runs
+// on top, and the canonical (re-marshalled, fixed field order) JSON is persisted as a new ConfigRevision -- all inside
+// a single transaction, so a mid-snapshot error leaves the previous config untouched.
+func (m *Manager) applyConfigSnapshot(rawJSON []byte) (*ConfigRevision, error) {
+	var snapshot ConfigSnapshotV1
+	if err := json.Unmarshal(rawJSON, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse config snapshot: %w", err)
+	}
+
+	canonicalJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize config snapshot: %w", err)
+	}
+	sum := sha256.Sum256(canonicalJSON)
+	sha256Hex := hex.EncodeToString(sum[:])
+
+	var revisionRecord *ConfigRevision
+	err = m.ReadWriteDB.Transaction(func(tx *gorm.DB) error {
+		if err := applySnapshotRows(tx, &snapshot, m.Config.ACME != nil && m.Config.ACME.Enabled); err != nil {
+			return err
+		}
+		if err := cleanupUnusedBackendsAndListeners(tx); err != nil {
+			return fmt.Errorf("failed to clean up unused backends/listeners: %w", err)
+		}
+
+		revision, err := nextConfigRevision(tx)
+		if err != nil {
+			return fmt.Errorf("failed to allocate config revision: %w", err)
+		}
+		revisionRecord, err = createConfigRevision(tx, revision, string(canonicalJSON), sha256Hex)
+		if err != nil {
+			return fmt.Errorf("failed to persist config revision: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m.BroadcastChangesToProxies(adsResourceTypeURLs...)
+	return revisionRecord, nil
+}
+
+// rollbackToRevision re-applies a previously accepted ConfigRevision's snapshot through the same
+// applyConfigSnapshot path, producing a new revision rather than reviving the old one -- so the
+// revision history always reads as a straight line of what was actually applied, in order.
+func (m *Manager) rollbackToRevision(revision int64) (*ConfigRevision, error) {
+	record, err := getConfigRevision(m.ReadOnlyDB, revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up revision %d: %w", revision, err)
+	}
+	if record == nil {
+		return nil, fmt.Errorf("unknown config revision: %d", revision)
+	}
+	return m.applyConfigSnapshot([]byte(record.SnapshotJSON))
+}
+
+// applySnapshotRows upserts every row named in snapshot and deletes whatever of the same kind
+// isn't, within tx. Each section validates and applies through the same Process method the
+// per-event pipeline uses, so a snapshot can't bypass event-path validation.
+//
+// acmeEnabled keeps the HTTP-01 challenge IngressRule that registerHTTP01IngressRule (acme.go)
+// maintains out of the delete set even though no submitted IngressRuleUpsertV1 names it --
+// otherwise every snapshot apply would tear down ACME's validation route until the process next
+// restarts. TLSCertificate rows get the equivalent carve-out unconditionally, since Managed is
+// already a per-row property (see deleteTLSCertificatesNotIn).
+func applySnapshotRows(tx *gorm.DB, snapshot *ConfigSnapshotV1, acmeEnabled bool) error {
+	keepCertIDs := make([]string, 0, len(snapshot.TLSCertificates))
+	for i := range snapshot.TLSCertificates {
+		cert := &snapshot.TLSCertificates[i]
+		if _, err := cert.Process(tx); err != nil {
+			return fmt.Errorf("tls_certificates[%d]: %w", i, err)
+		}
+		keepCertIDs = append(keepCertIDs, getTLSCertificateID(cert.Domain, cert.IsWildcard))
+	}
+	if err := deleteTLSCertificatesNotIn(tx, keepCertIDs); err != nil {
+		return fmt.Errorf("failed to delete stale tls certificates: %w", err)
+	}
+
+	keepIngressIDs := make([]string, 0, len(snapshot.IngressRules)+1)
+	for i := range snapshot.IngressRules {
+		rule := &snapshot.IngressRules[i]
+		if _, err := rule.Process(tx); err != nil {
+			return fmt.Errorf("ingress_rules[%d]: %w", i, err)
+		}
+		keepIngressIDs = append(keepIngressIDs, getIngressRuleID(rule.Protocol, getListenerID(rule.BindIP, rule.Port), rule.Domain, rule.RoutePrefix))
+	}
+	if acmeEnabled {
+		keepIngressIDs = append(keepIngressIDs, http01IngressRuleID())
+	}
+	if err := deleteIngressRulesNotIn(tx, keepIngressIDs); err != nil {
+		return fmt.Errorf("failed to delete stale ingress rules: %w", err)
+	}
+
+	keepRedirectIDs := make([]string, 0, len(snapshot.HTTPRedirectRules))
+	for i := range snapshot.HTTPRedirectRules {
+		rule := &snapshot.HTTPRedirectRules[i]
+		if _, err := rule.Process(tx); err != nil {
+			return fmt.Errorf("http_redirect_rules[%d]: %w", i, err)
+		}
+		keepRedirectIDs = append(keepRedirectIDs, getHTTPRedirectRuleID(getListenerID(rule.BindIP, rule.Port), rule.Domain, rule.RoutePrefix, rule.IsHttpsRedirect))
+	}
+	return deleteHTTPRedirectRulesNotIn(tx, keepRedirectIDs)
+}
@@ -21,6 +21,26 @@ type BackendResolverType string
 const (
 	STATIC_RESOLVER BackendResolverType = "static"
 	DNS_RESOLVER    BackendResolverType = "dns"
+
+	// DOT_RESOLVER and DOH_RESOLVER resolve BackendHosts over DNS-over-TLS / DNS-over-HTTPS
+	// instead of plain UDP/TCP DNS. BackendDNSResolver is the upstream's address (host:port for
+	// DoT, a full URL for DoH); BackendDNSServerName/BootstrapIPs/CABundle below control how the
+	// proxy validates that upstream's certificate. The proxy owns the actual resolution, caching
+	// (honoring each answer's TTL) and cache hit/miss + latency metrics -- this repo only models,
+	// validates and pushes the config it resolves against.
+	DOT_RESOLVER BackendResolverType = "dot"
+	DOH_RESOLVER BackendResolverType = "doh"
+)
+
+// LBPolicy selects how a proxy distributes requests across a Backend's currently healthy hosts.
+// See SelectBackendHost for the selection logic each policy implements.
+type LBPolicy string
+
+const (
+	LBRoundRobin LBPolicy = "round_robin"
+	LBLeastConn  LBPolicy = "least_conn"
+	LBRandom     LBPolicy = "random"
+	LBIPHash     LBPolicy = "ip_hash"
 )
 
 type MessageInterface interface {
@@ -33,6 +53,8 @@ type MessageInterface interface {
 var (
 	_ MessageInterface = (*TLSCertificateUpsertV1)(nil)
 	_ MessageInterface = (*TLSCertificateDeleteV1)(nil)
+	_ MessageInterface = (*TLSCertificateACMEConfigV1)(nil)
+	_ MessageInterface = (*TLSCertificateACMERequestV1)(nil)
 	_ MessageInterface = (*IngressRuleUpsertV1)(nil)
 	_ MessageInterface = (*IngressRuleDeleteV1)(nil)
 	_ MessageInterface = (*HTTPRedirectRuleUpsertV1)(nil)
@@ -40,8 +62,12 @@ var (
 )
 
 type CommonEventParamsV1 struct {
-	RequestID   string    `json:"request_id"`
-	RequestedAt time.Time `json:"requested_at"`
+	// RequestID/RequestedAt are excluded from hashstructure hashing (hash:"ignore") because
+	// desiredResource.hash (k8sgateway.go) hashes whole upsert payloads that embed this struct to
+	// detect no-op reconciles -- a fresh RequestID/RequestedAt on every translate call would
+	// otherwise make every hash differ even when nothing else changed.
+	RequestID   string    `json:"request_id" hash:"ignore"`
+	RequestedAt time.Time `json:"requested_at" hash:"ignore"`
 }
 
 type ResponsePayloadV1 struct {
@@ -69,6 +95,35 @@ type TLSCertificateDeleteV1 struct {
 	IsWildcard bool   `json:"is_wildcard"`
 }
 
+// TLSCertificateACMEConfigV1 selects, per domain, which ACME challenge type (and dns-01
+// provider/credentials) the renewer (see acme.go) uses when it issues or renews that domain's
+// certificate. Processing it only upserts the matching ACMEChallenge row; the renewer itself
+// picks the change up on its next scheduled pass.
+type TLSCertificateACMEConfigV1 struct {
+	CommonEventParamsV1
+	Domain string `json:"domain"`
+
+	// ChallengeType is one of "http-01" or "dns-01". Defaults to "http-01" when left empty.
+	ChallengeType string `json:"challenge_type"`
+
+	// DNSProvider is one of "cloudflare", "route53", "rfc2136" and only applies to dns-01.
+	// DNSProviderConfig holds that provider's credentials as a JSON blob (see dns_providers.go).
+	DNSProvider       string `json:"dns_provider,omitempty"`
+	DNSProviderConfig string `json:"dns_provider_config,omitempty"`
+}
+
+// TLSCertificateACMERequestV1 asks the ACME renewer (see acme.go) to obtain -- or immediately
+// renew -- a certificate for Domain. Processing it marks the domain's TLSCertificate row Managed
+// and due (empty cert, zero ExpiresAt), so renewDueCertificates picks it up on its next tick
+// instead of waiting for the domain to surface via domainsNeedingACMECert. A request with
+// IsWildcard set is stored the same way, but renewDueCertificates skips wildcard rows, so no
+// certificate is issued for it.
+type TLSCertificateACMERequestV1 struct {
+	CommonEventParamsV1
+	Domain     string `json:"domain"`
+	IsWildcard bool   `json:"is_wildcard"`
+}
+
 type IngressRuleUpsertV1 struct {
 	CommonEventParamsV1
 
@@ -90,8 +145,108 @@ type IngressRuleUpsertV1 struct {
 	BackendHosts       []string            `json:"backend_hosts"` // For DNS Based Resolver, pass one value strictly
 	BackendPort        int                 `json:"backend_port"`
 
+	// BackendDNSServerName pins the hostname the proxy verifies BackendDNSResolver's certificate
+	// against, for DOT_RESOLVER/DOH_RESOLVER. Defaults to the host portion of BackendDNSResolver
+	// when left empty.
+	BackendDNSServerName string `json:"backend_dns_server_name,omitempty"`
+	// BackendDNSBootstrapIPs resolves BackendDNSResolver itself when it's a hostname, so the
+	// proxy doesn't need a working plain DNS resolver just to reach a secure one.
+	BackendDNSBootstrapIPs []string `json:"backend_dns_bootstrap_ips,omitempty"`
+	// BackendDNSCABundle is a PEM bundle the proxy trusts BackendDNSResolver's certificate
+	// against, in addition to the system trust store. Leave empty to trust the system store only.
+	BackendDNSCABundle string `json:"backend_dns_ca_bundle,omitempty"`
+
 	BackendIsTLS     bool   `json:"backend_is_tls"`
 	BackendSNIDomain string `json:"backend_sni_domain"`
+
+	// BackendLBPolicy picks how traffic is spread across BackendHosts; empty defaults to
+	// round_robin (see upsertBackend).
+	BackendLBPolicy LBPolicy `json:"backend_lb_policy"`
+
+	// Active health check config for the backend. See health.go for the probing supervisor and
+	// Backend in models.go for the defaults applied when these are left zero.
+	BackendHealthCheckPath            string `json:"backend_health_check_path"`
+	BackendHealthCheckIntervalSeconds int    `json:"backend_health_check_interval_seconds"`
+	BackendHealthyThreshold           int    `json:"backend_healthy_threshold"`
+	BackendUnhealthyThreshold         int    `json:"backend_unhealthy_threshold"`
+	BackendExpectedStatus             int    `json:"backend_expected_status"`
+	// BackendHealthCheckFailOpen keeps every host in rotation when all of them are unhealthy,
+	// rather than taking the backend fully out of service.
+	BackendHealthCheckFailOpen bool `json:"backend_health_check_fail_open"`
+
+	// Middlewares runs in the given order in front of the backend. See MiddlewareType for the
+	// supported kinds and validateMiddlewareConfig for each one's expected Config shape.
+	Middlewares []MiddlewareSpecV1 `json:"middlewares"`
+
+	// Backends, when set, fans this rule out across multiple weighted backend pools instead of
+	// the single implicit one described by the Backend* fields above -- see
+	// SelectIngressRuleBackends in poolhealth.go for how weight/IsBackup/PoolHealthCheck affect
+	// routing. Leave it empty to keep using the single-backend Backend* fields; a non-empty
+	// Backends replaces them entirely.
+	Backends []BackendSpecV1 `json:"backends,omitempty"`
+}
+
+// MiddlewareSpecV1 describes one entry in an IngressRule's middleware chain. Config is
+// marshalled as-is into MiddlewareChain.Config; its shape depends on Type.
+type MiddlewareSpecV1 struct {
+	Type   MiddlewareType  `json:"type"`
+	Config json.RawMessage `json:"config"`
+}
+
+// BackendSpecV1 describes one pool in an IngressRule's Backends fan-out. Its resolver/host/DNS/
+// backend-LB fields mirror IngressRuleUpsertV1's single-backend Backend* fields exactly and feed
+// the same upsertBackend call; Weight/IsBackup/PoolHealthCheck are new, only meaningful when a
+// rule has more than one backend pool.
+type BackendSpecV1 struct {
+	BackendResolver    BackendResolverType `json:"backend_resolver"`
+	BackendDNSResolver string              `json:"backend_dns_resolver"`
+	BackendHosts       []string            `json:"backend_hosts"`
+	BackendPort        int                 `json:"backend_port"`
+
+	BackendDNSServerName   string   `json:"backend_dns_server_name,omitempty"`
+	BackendDNSBootstrapIPs []string `json:"backend_dns_bootstrap_ips,omitempty"`
+	BackendDNSCABundle     string   `json:"backend_dns_ca_bundle,omitempty"`
+
+	BackendIsTLS     bool   `json:"backend_is_tls"`
+	BackendSNIDomain string `json:"backend_sni_domain"`
+
+	BackendLBPolicy LBPolicy `json:"backend_lb_policy"`
+
+	BackendHealthCheckPath            string `json:"backend_health_check_path"`
+	BackendHealthCheckIntervalSeconds int    `json:"backend_health_check_interval_seconds"`
+	BackendHealthyThreshold           int    `json:"backend_healthy_threshold"`
+	BackendUnhealthyThreshold         int    `json:"backend_unhealthy_threshold"`
+	BackendExpectedStatus             int    `json:"backend_expected_status"`
+	BackendHealthCheckFailOpen        bool   `json:"backend_health_check_fail_open"`
+
+	// Weight controls this pool's share of traffic relative to the rule's other non-backup
+	// pools, via SelectWeightedIngressRuleBackend. Defaults to 1 when left zero.
+	Weight int `json:"weight,omitempty"`
+	// IsBackup excludes this pool from normal weighted selection; it's only used when every
+	// non-backup pool is unhealthy per PoolHealthCheck.
+	IsBackup bool `json:"is_backup,omitempty"`
+
+	// PoolHealthCheck, when set, is actively probed by PoolHealthChecker (see poolhealth.go) to
+	// decide whether this pool counts as healthy for primary/backup failover. Leave it unset to
+	// always consider the pool healthy.
+	PoolHealthCheck *PoolHealthCheckSpecV1 `json:"pool_health_check,omitempty"`
+}
+
+// PoolHealthCheckSpecV1 configures the in-memory, pool-level probe PoolHealthChecker runs against
+// a backend pool to decide primary/backup failover -- distinct from the per-host, DB-persisted
+// checks described by BackendSpecV1's own BackendHealthCheck* fields (see health.go).
+type PoolHealthCheckSpecV1 struct {
+	// Path is the HTTP path to probe. Leave empty with TCPOnly set to just dial the host.
+	Path    string `json:"path,omitempty"`
+	TCPOnly bool   `json:"tcp_only,omitempty"`
+
+	IntervalSeconds    int `json:"interval_seconds,omitempty"`
+	TimeoutSeconds     int `json:"timeout_seconds,omitempty"`
+	HealthyThreshold   int `json:"healthy_threshold,omitempty"`
+	UnhealthyThreshold int `json:"unhealthy_threshold,omitempty"`
+
+	// ExpectedStatusCodes lists the HTTP status codes that count as healthy. Empty means any 2xx.
+	ExpectedStatusCodes []string `json:"expected_status_codes,omitempty"`
 }
 
 type IngressRuleDeleteV1 struct {
@@ -123,6 +278,18 @@ type HTTPRedirectRuleUpsertV1 struct {
 	HostRedirect   string `json:"host_redirect"`
 	PathRedirect   string `json:"path_redirect"`
 	StatusCode     int    `json:"status_code"`
+
+	// PathRegex + PathReplacement rewrite RoutePrefix-matched paths with Go's regexp.Expand
+	// syntax instead of the plain PathRedirect substitution, when PathRegex is set. See
+	// compileCachedPathRegex and validatePathReplacement in redirect.go.
+	PathRegex       string `json:"path_regex,omitempty"`
+	PathReplacement string `json:"path_replacement,omitempty"`
+
+	// PreserveQuery appends the original request's query string to the redirect Location.
+	PreserveQuery bool `json:"preserve_query"`
+
+	// ResponseHeaders are merged into the redirect response alongside Location.
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
 }
 
 type HTTPRedirectRuleDeleteV1 struct {
@@ -224,3 +391,49 @@ func (s *StringList) UnmarshalJSON(data []byte) error {
 	*s = result
 	return nil
 }
+
+// StringMap is a GORM-compatible custom type that stores map[string]string as JSON text in the
+// DB, the map analogue of StringList.
+type StringMap map[string]string
+
+// Scan implements sql.Scanner.
+func (m *StringMap) Scan(value interface{}) error {
+	if value == nil {
+		*m = StringMap{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("failed to scan StringMap: expected []byte or string, got %T", value)
+	}
+
+	if len(bytes) == 0 {
+		*m = StringMap{}
+		return nil
+	}
+
+	result := StringMap{}
+	if err := json.Unmarshal(bytes, &result); err != nil {
+		return fmt.Errorf("failed to unmarshal StringMap: %w", err)
+	}
+	*m = result
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (m StringMap) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return "{}", nil
+	}
+	bytes, err := json.Marshal(map[string]string(m))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal StringMap: %w", err)
+	}
+	return string(bytes), nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/ecdsa"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -50,6 +51,8 @@ func ValidateCertAndKey(certPEM, keyPEM string) error {
 	switch keyBlock.Type {
 	case "RSA PRIVATE KEY":
 		privKey, err = x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	case "EC PRIVATE KEY":
+		privKey, err = x509.ParseECPrivateKey(keyBlock.Bytes)
 	case "PRIVATE KEY":
 		privKey, err = x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
 	default:
@@ -69,6 +72,14 @@ func ValidateCertAndKey(certPEM, keyPEM string) error {
 		if rsaPub.N.Cmp(key.PublicKey.N) != 0 {
 			return errors.New("certificate and key do not match")
 		}
+	case *ecdsa.PrivateKey:
+		ecPub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("certificate public key is not ECDSA")
+		}
+		if ecPub.X.Cmp(key.PublicKey.X) != 0 || ecPub.Y.Cmp(key.PublicKey.Y) != 0 {
+			return errors.New("certificate and key do not match")
+		}
 	default:
 		return errors.New("unsupported private key type for validation")
 	}